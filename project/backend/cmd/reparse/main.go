@@ -0,0 +1,109 @@
+// Command reparse rebuilds forums, topics and posts from the raw page archive,
+// without contacting the forum. Run it after fixing a parser bug.
+//
+//	reparse -archive ./data/archive
+//
+// The database is configured like the server's, see internal/config; pending
+// migrations are applied first, and the counters are reconciled at the end.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"forum-api-wrapper/internal/archive"
+	"forum-api-wrapper/internal/config"
+	"forum-api-wrapper/internal/migrate"
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scraper"
+	"forum-api-wrapper/internal/service"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("reparse: %v", err)
+	}
+	archiveDir := flag.String("archive", cfg.ArchiveDir, "directory of the page archive")
+	flag.Parse()
+
+	if *archiveDir == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	store, err := archive.NewStore(*archiveDir)
+	if err != nil {
+		log.Fatalf("reparse: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	db, err := openDB(ctx, cfg)
+	if err != nil {
+		log.Fatalf("reparse: %v", err)
+	}
+	defer db.Close()
+
+	// The base URL is never used: Reparse only reads the archive
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper("", repo, scraper.Options{})
+	stats := &scraper.Stats{}
+	reparseErr := s.Reparse(scraper.WithStats(ctx, stats), store)
+
+	// Reparsed pages overwrite stored rows without touching the counters, so
+	// they are reconciled like after a sync, even when the reparse failed
+	job, err := service.NewService(repo).RunSync(context.WithoutCancel(ctx), service.SyncRequest{Scope: service.SyncScopeCounters}, service.SyncTriggerManual)
+	if err != nil {
+		reparseErr = errors.Join(reparseErr, err)
+	} else if job.Status == models.SyncJobFailed {
+		reparseErr = errors.Join(reparseErr, fmt.Errorf("sync job %d failed to reconcile counters", job.ID))
+	} else {
+		log.Printf("reparse: corrected %d drifted counters (sync job %d)", job.CountersCorrected, job.ID)
+	}
+
+	if reparseErr != nil {
+		log.Printf("reparse: finished with errors:\n%v", reparseErr)
+		log.Printf("reparse: %d rows upserted", stats.RowsUpserted())
+		os.Exit(1)
+	}
+	log.Printf("reparse: done, %d rows upserted", stats.RowsUpserted())
+}
+
+// openDB opens the configured database and applies pending migrations, as the
+// server does on start
+func openDB(ctx context.Context, cfg *config.Config) (*sql.DB, error) {
+	db, err := sql.Open(cfg.DBDriver, cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	migrator, err := migrate.NewMigrator(db, cfg.DBDriver)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	for _, m := range applied {
+		log.Printf("reparse: applied migration %d_%s", m.Version, m.Name)
+	}
+	return db, nil
+}
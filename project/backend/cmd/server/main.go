@@ -0,0 +1,179 @@
+// Command server serves the forum API. It is configured through the
+// environment, see internal/config:
+//
+//	DB_DRIVER=postgres DATABASE_URL=postgres://... PORT=8080 server
+//
+// Pending schema migrations are applied on start. They can also be managed
+// without starting the server:
+//
+//	server migrate up|down|status
+//
+// When FORUM_URL is set the forum is synced on a schedule, and on request
+// through the admin endpoints when ADMIN_TOKEN is set.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"forum-api-wrapper/internal/api"
+	"forum-api-wrapper/internal/archive"
+	"forum-api-wrapper/internal/config"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scheduler"
+	"forum-api-wrapper/internal/scraper"
+	"forum-api-wrapper/internal/service"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("server: %v", err)
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		log.Fatalf("server: %v", err)
+	}
+	defer db.Close()
+
+	// SIGTERM is how Render and docker-compose stop the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		code := runMigrate(ctx, db, cfg.DBDriver, os.Args[2:])
+		db.Close()
+		os.Exit(code)
+	}
+	if err := migrateUp(ctx, db, cfg.DBDriver); err != nil {
+		log.Fatalf("server: %v", err)
+	}
+
+	repo := repository.NewRepository(db)
+	svc := service.NewService(repo)
+
+	var sched *scheduler.Scheduler
+	if cfg.ForumURL != "" {
+		s, err := newScraper(cfg, repo)
+		if err != nil {
+			log.Fatalf("server: %v", err)
+		}
+		svc.SetSyncer(s)
+
+		schedule := scheduler.DefaultConfig()
+		schedule.HotForums = cfg.HotForums
+		sched = scheduler.NewScheduler(scheduler.SyncJobs(svc.ScheduledSyncer(), schedule)...)
+		sched.Start(ctx)
+	} else {
+		log.Printf("server: FORUM_URL is not set, sync is disabled")
+	}
+
+	if cfg.AdminToken == "" {
+		log.Printf("server: ADMIN_TOKEN is not set, admin endpoints are disabled")
+	}
+	router := gin.Default()
+	api.RegisterRoutes(router, api.NewHandler(svc), cfg.AdminToken)
+
+	srv := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Port),
+		Handler: router,
+	}
+	served := make(chan error, 1)
+	go func() {
+		log.Printf("server: listening on %s", srv.Addr)
+		served <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-served:
+		log.Fatalf("server: %v", err)
+	case <-ctx.Done():
+	}
+	// A second signal stops the server without waiting
+	stop()
+
+	log.Printf("server: shutting down, waiting up to %s", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	shutdown(shutdownCtx, srv, svc, sched)
+}
+
+// shutdown stops the server within ctx: it stops accepting connections and
+// drains in-flight requests, then cancels running syncs and waits for them to
+// record their outcome. The database is closed by the caller afterwards.
+func shutdown(ctx context.Context, srv *http.Server, svc *service.Service, sched *scheduler.Scheduler) {
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("server: failed to drain requests: %v", err)
+	}
+	// Requests are drained first, as they may have started syncs
+	if err := svc.Shutdown(ctx); err != nil {
+		log.Printf("server: %v", err)
+	}
+	if sched != nil {
+		// Scheduled runs are syncs too, so they have already been cancelled
+		stopped := make(chan struct{})
+		go func() {
+			sched.Stop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			log.Printf("server: failed to stop the scheduler: %v", ctx.Err())
+		}
+	}
+	log.Printf("server: stopped")
+}
+
+// openDB opens and checks the configured database
+func openDB(cfg *config.Config) (*sql.DB, error) {
+	db, err := sql.Open(cfg.DBDriver, cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if cfg.DBDriver == "sqlite3" {
+		// SQLite allows one writer at a time; sharing one connection makes
+		// concurrent requests and syncs wait for each other instead of failing
+		db.SetMaxOpenConns(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
+
+// newScraper creates the scraper syncs run with, archiving and caching pages
+// when directories for them are configured
+func newScraper(cfg *config.Config, repo repository.Repository) (*scraper.Scraper, error) {
+	opts := scraper.DefaultOptions()
+	if cfg.ArchiveDir != "" {
+		store, err := archive.NewStore(cfg.ArchiveDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open page archive: %w", err)
+		}
+		opts.Archive = store
+	}
+	if cfg.CacheDir != "" {
+		cache, err := scraper.NewDiskCache(cfg.CacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open page cache: %w", err)
+		}
+		opts.Cache = cache
+	}
+	return scraper.NewScraper(cfg.ForumURL, repo, opts), nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"forum-api-wrapper/internal/migrate"
+)
+
+const migrateUsage = "usage: server migrate up|down|status"
+
+// migrateUp applies pending migrations before the server starts. Instances
+// started together take turns through the migration lock.
+func migrateUp(ctx context.Context, db *sql.DB, driver string) error {
+	migrator, err := migrate.NewMigrator(db, driver)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range applied {
+		log.Printf("server: applied migration %d_%s", m.Version, m.Name)
+	}
+	return nil
+}
+
+// runMigrate runs the migrate command with args and returns the exit code:
+//
+//	server migrate up      apply every pending migration
+//	server migrate down    roll back the latest applied migration
+//	server migrate status  list migrations and when they were applied
+func runMigrate(ctx context.Context, db *sql.DB, driver string, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	migrator, err := migrate.NewMigrator(db, driver)
+	if err != nil {
+		log.Printf("migrate: %v", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Printf("migrate: %v", err)
+			return 1
+		}
+		for _, m := range applied {
+			fmt.Printf("applied %d_%s\n", m.Version, m.Name)
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		rolledBack, err := migrator.Down(ctx)
+		if err != nil {
+			log.Printf("migrate: %v", err)
+			return 1
+		}
+		if rolledBack == nil {
+			fmt.Println("no migration to roll back")
+		} else {
+			fmt.Printf("rolled back %d_%s\n", rolledBack.Version, rolledBack.Name)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Printf("migrate: %v", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		w.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
@@ -3,8 +3,10 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"forum-api-wrapper/internal/content"
 	"forum-api-wrapper/internal/repository"
 	"forum-api-wrapper/internal/service"
 )
@@ -93,6 +95,32 @@ func (h *Handler) GetTopic(c *gin.Context) {
 	}
 
 	page, limit := parsePagination(c)
+	format, ok := parseFormat(c)
+	if !ok {
+		return
+	}
+
+	view := c.DefaultQuery("view", "flat")
+	if view != "flat" && view != "tree" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid view: must be flat or tree"})
+		return
+	}
+
+	if view == "tree" {
+		response, err := h.service.GetTopicTree(c.Request.Context(), id, page, limit)
+		if err != nil {
+			if err.Error() == "topic not found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "topic not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		service.FormatTree(response.Thread, format)
+		c.JSON(http.StatusOK, response)
+		return
+	}
 
 	response, err := h.service.GetTopic(c.Request.Context(), id, page, limit)
 	if err != nil {
@@ -104,6 +132,53 @@ func (h *Handler) GetTopic(c *gin.Context) {
 		return
 	}
 
+	service.FormatPosts(response.Posts, format)
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTopicBacklinks handles GET /topics/:topicId/backlinks
+func (h *Handler) GetTopicBacklinks(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("topicId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid topic ID"})
+		return
+	}
+
+	page, limit := parsePagination(c)
+
+	response, err := h.service.GetTopicBacklinks(c.Request.Context(), id, page, limit)
+	if err != nil {
+		if err.Error() == "topic not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "topic not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTopicOutlinks handles GET /topics/:topicId/outlinks
+func (h *Handler) GetTopicOutlinks(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("topicId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid topic ID"})
+		return
+	}
+
+	page, limit := parsePagination(c)
+
+	response, err := h.service.GetTopicOutlinks(c.Request.Context(), id, page, limit)
+	if err != nil {
+		if err.Error() == "topic not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "topic not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -127,12 +202,18 @@ func (h *Handler) GetPosts(c *gin.Context) {
 		}
 	}
 
+	format, ok := parseFormat(c)
+	if !ok {
+		return
+	}
+
 	response, err := h.service.GetPosts(c.Request.Context(), filter, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	service.FormatPosts(response.Posts, format)
 	c.JSON(http.StatusOK, response)
 }
 
@@ -143,6 +224,10 @@ func (h *Handler) GetPost(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
 		return
 	}
+	format, ok := parseFormat(c)
+	if !ok {
+		return
+	}
 
 	post, err := h.service.GetPost(c.Request.Context(), id)
 	if err != nil {
@@ -154,9 +239,57 @@ func (h *Handler) GetPost(c *gin.Context) {
 		return
 	}
 
+	service.FormatPost(post, format)
 	c.JSON(http.StatusOK, post)
 }
 
+// GetPostRevisions handles GET /posts/:postId/revisions
+func (h *Handler) GetPostRevisions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("postId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		return
+	}
+
+	response, err := h.service.GetPostRevisions(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "post not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPostReplies handles GET /posts/:postId/replies
+func (h *Handler) GetPostReplies(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("postId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		return
+	}
+	format, ok := parseFormat(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.GetPostReplies(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "post not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	service.FormatPosts(response.Replies, format)
+	c.JSON(http.StatusOK, response)
+}
+
 // GetUsers handles GET /users
 func (h *Handler) GetUsers(c *gin.Context) {
 	page, limit := parsePagination(c)
@@ -210,15 +343,201 @@ func (h *Handler) Search(c *gin.Context) {
 		}
 	}
 
+	format, ok := parseFormat(c)
+	if !ok {
+		return
+	}
+
 	response, err := h.service.Search(c.Request.Context(), query, searchType, forumID, page, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	service.FormatPosts(response.Results.Posts, format)
+	c.JSON(http.StatusOK, response)
+}
+
+// TriggerSync handles POST /admin/sync
+func (h *Handler) TriggerSync(c *gin.Context) {
+	var req service.SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	job, err := h.service.StartSync(c.Request.Context(), req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid sync request") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "sync already running") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "sync is not configured" || err.Error() == "sync is shutting down" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetSyncJobs handles GET /admin/sync/jobs
+func (h *Handler) GetSyncJobs(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	response, err := h.service.GetSyncJobs(c.Request.Context(), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSyncJob handles GET /admin/sync/jobs/:jobId
+func (h *Handler) GetSyncJob(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sync job ID"})
+		return
+	}
+
+	job, err := h.service.GetSyncJob(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "sync job not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "sync job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// GetSyncJobDrift handles GET /admin/sync/jobs/:jobId/drift
+func (h *Handler) GetSyncJobDrift(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("jobId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sync job ID"})
+		return
+	}
+
+	page, limit := parsePagination(c)
+
+	response, err := h.service.GetSyncJobDrift(c.Request.Context(), id, page, limit)
+	if err != nil {
+		if err.Error() == "sync job not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "sync job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetQuarantinedPages handles GET /admin/quarantine
+func (h *Handler) GetQuarantinedPages(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	response, err := h.service.GetQuarantinedPages(c.Request.Context(), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetQuarantinedPage handles GET /admin/quarantine/:pageId
+func (h *Handler) GetQuarantinedPage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("pageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quarantined page ID"})
+		return
+	}
+
+	page, err := h.service.GetQuarantinedPage(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "quarantined page not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "quarantined page not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// RetryQuarantinedPage handles POST /admin/quarantine/:pageId/retry
+func (h *Handler) RetryQuarantinedPage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("pageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quarantined page ID"})
+		return
+	}
+
+	response, err := h.service.RetryQuarantinedPage(c.Request.Context(), id)
+	if err != nil {
+		if err.Error() == "quarantined page not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "quarantined page not found"})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "sync already running") {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "sync is not configured" || err.Error() == "sync is shutting down" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
+// DiscardQuarantinedPage handles DELETE /admin/quarantine/:pageId
+func (h *Handler) DiscardQuarantinedPage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("pageId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quarantined page ID"})
+		return
+	}
+
+	if err := h.service.DiscardQuarantinedPage(c.Request.Context(), id); err != nil {
+		if err.Error() == "quarantined page not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "quarantined page not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseFormat parses the content format of posts from the format query parameter.
+// An unknown format is answered with 400 and reported as not ok.
+func parseFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", content.HTML)
+	if !content.ValidFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format: must be html, markdown or text"})
+		return "", false
+	}
+	return format, true
+}
+
 // parsePagination parses page and limit from query parameters
 func parsePagination(c *gin.Context) (int, int) {
 	page := 1
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registers the API under /api on router. The admin endpoints
+// are only registered when adminToken is set, and require it as a bearer token.
+func RegisterRoutes(router gin.IRouter, handler *Handler, adminToken string) {
+	apiGroup := router.Group("/api")
+	{
+		apiGroup.GET("/health", handler.HealthCheck)
+		apiGroup.GET("/forums", handler.GetForums)
+		apiGroup.GET("/forums/:id", handler.GetForum)
+		apiGroup.GET("/topics", handler.GetTopics)
+		apiGroup.GET("/topics/:topicId", handler.GetTopic)
+		apiGroup.GET("/topics/:topicId/backlinks", handler.GetTopicBacklinks)
+		apiGroup.GET("/topics/:topicId/outlinks", handler.GetTopicOutlinks)
+		apiGroup.GET("/posts", handler.GetPosts)
+		apiGroup.GET("/posts/:postId", handler.GetPost)
+		apiGroup.GET("/posts/:postId/revisions", handler.GetPostRevisions)
+		apiGroup.GET("/posts/:postId/replies", handler.GetPostReplies)
+		apiGroup.GET("/users", handler.GetUsers)
+		apiGroup.GET("/users/:userId", handler.GetUser)
+		apiGroup.GET("/search", handler.Search)
+	}
+
+	if adminToken == "" {
+		return
+	}
+	adminGroup := apiGroup.Group("/admin", requireToken(adminToken))
+	{
+		adminGroup.POST("/sync", handler.TriggerSync)
+		adminGroup.GET("/sync/jobs", handler.GetSyncJobs)
+		adminGroup.GET("/sync/jobs/:jobId", handler.GetSyncJob)
+		adminGroup.GET("/sync/jobs/:jobId/drift", handler.GetSyncJobDrift)
+		adminGroup.GET("/quarantine", handler.GetQuarantinedPages)
+		adminGroup.GET("/quarantine/:pageId", handler.GetQuarantinedPage)
+		adminGroup.POST("/quarantine/:pageId/retry", handler.RetryQuarantinedPage)
+		adminGroup.DELETE("/quarantine/:pageId", handler.DiscardQuarantinedPage)
+	}
+}
+
+// requireToken rejects requests without "Authorization: Bearer <token>"
+func requireToken(token string) gin.HandlerFunc {
+	want := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		got := []byte(c.GetHeader("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or missing admin token"})
+			return
+		}
+		c.Next()
+	}
+}
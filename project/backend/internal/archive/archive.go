@@ -0,0 +1,215 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// indexFile lists every archived fetch, one JSON record per line
+const indexFile = "index.jsonl"
+
+// Record describes one archived fetch. The body lives in the object store under its digest.
+type Record struct {
+	URL string `json:"url"`
+	// Path is the page's path relative to the forum root, which identifies the
+	// page whatever the forum's base URL (empty in records archived without it)
+	Path       string      `json:"path,omitempty"`
+	FetchedAt  time.Time   `json:"fetchedAt"`
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	// Digest is "sha256:" followed by the hex digest of the raw, undecoded body
+	Digest string `json:"digest"`
+	Size   int    `json:"size"`
+}
+
+// Store is a content-addressed archive of fetched pages. Bodies are stored
+// gzipped once per distinct content under objects/, and every fetch is appended
+// to index.jsonl, so refetching an unchanged page only costs an index line.
+type Store struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewStore opens the archive rooted at dir, creating the directory if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "objects"), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Put archives body and appends rec to the index. Digest and Size are set from body.
+func (s *Store) Put(rec *Record, body []byte) error {
+	sum := sha256.Sum256(body)
+	hexSum := hex.EncodeToString(sum[:])
+	rec.Digest = "sha256:" + hexSum
+	rec.Size = len(body)
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode archive record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objectPath := s.objectPath(hexSum)
+	if _, err := os.Stat(objectPath); errors.Is(err, os.ErrNotExist) {
+		if err := s.writeObject(objectPath, body); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to stat archive object: %w", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, indexFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open archive index: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to append to archive index: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to append to archive index: %w", err)
+	}
+	return nil
+}
+
+// Records returns every archived fetch in the order it was archived. A
+// truncated last line, left by a crash mid-write, is ignored.
+func (s *Store) Records() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(filepath.Join(s.dir, indexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive index: %w", err)
+	}
+	defer f.Close()
+
+	var records []Record
+	reader := bufio.NewReader(f)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// Only a line ending in a newline was written completely
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive index: %w", err)
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, fmt.Errorf("failed to decode archive index line %d: %w", lineNo, err)
+		}
+		records = append(records, rec)
+	}
+}
+
+// Latest returns the most recent record of every archived URL, sorted by URL
+func (s *Store) Latest() ([]Record, error) {
+	records, err := s.Records()
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[string]Record, len(records))
+	for _, rec := range records {
+		if prev, ok := latest[rec.URL]; !ok || !rec.FetchedAt.Before(prev.FetchedAt) {
+			latest[rec.URL] = rec
+		}
+	}
+
+	result := make([]Record, 0, len(latest))
+	for _, rec := range latest {
+		result = append(result, rec)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].URL < result[j].URL })
+	return result, nil
+}
+
+// Body returns the raw body of an archived fetch, verified against its digest
+func (s *Store) Body(rec Record) ([]byte, error) {
+	hexSum, ok := strings.CutPrefix(rec.Digest, "sha256:")
+	if !ok || len(hexSum) != sha256.Size*2 {
+		return nil, fmt.Errorf("unsupported archive digest %q", rec.Digest)
+	}
+
+	f, err := os.Open(s.objectPath(hexSum))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive object: %w", err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive object: %w", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive object: %w", err)
+	}
+
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != hexSum {
+		return nil, fmt.Errorf("archive object %s is corrupt", rec.Digest)
+	}
+	return body, nil
+}
+
+// objectPath returns where the body with the given hex digest is stored, fanned
+// out over subdirectories named after the first two digits
+func (s *Store) objectPath(hexSum string) string {
+	return filepath.Join(s.dir, "objects", hexSum[:2], hexSum[2:]+".gz")
+}
+
+// writeObject compresses body into a temporary file and renames it into place
+func (s *Store) writeObject(path string, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	zw := gzip.NewWriter(tmp)
+	if _, err := zw.Write(body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write archive object: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write archive object: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write archive object: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write archive object: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package archive
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore_PutAndLatest(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	body := []byte("<html>index</html>")
+	puts := []struct {
+		rec  Record
+		body []byte
+	}{
+		{Record{URL: "https://forum/", FetchedAt: first, StatusCode: 200}, body},
+		{Record{URL: "https://forum/topic.php?tid=1", FetchedAt: first, StatusCode: 200}, body},
+		{Record{URL: "https://forum/", FetchedAt: first.Add(time.Hour), StatusCode: 200,
+			Header: http.Header{"Content-Type": {"text/html; charset=windows-1251"}}}, []byte("<html>newer</html>")},
+	}
+	for _, p := range puts {
+		rec := p.rec
+		if err := store.Put(&rec, p.body); err != nil {
+			t.Fatalf("Put(%s) error: %v", rec.URL, err)
+		}
+	}
+
+	// Identical bodies share one object
+	objects, _ := filepath.Glob(filepath.Join(dir, "objects", "*", "*.gz"))
+	if len(objects) != 2 {
+		t.Errorf("got %d objects, want 2", len(objects))
+	}
+
+	records, err := store.Records()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+
+	latest, err := store.Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(latest) != 2 || latest[0].URL != "https://forum/" {
+		t.Fatalf("Latest() = %+v, want two URLs with the index first", latest)
+	}
+	if !latest[0].FetchedAt.Equal(first.Add(time.Hour)) {
+		t.Errorf("latest index fetched at %v, want the newer fetch", latest[0].FetchedAt)
+	}
+	if got := latest[0].Header.Get("Content-Type"); got != "text/html; charset=windows-1251" {
+		t.Errorf("header Content-Type = %q", got)
+	}
+
+	got, err := store.Body(latest[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "<html>newer</html>" {
+		t.Errorf("Body() = %q", got)
+	}
+}
+
+func TestStore_IgnoresTruncatedIndexLine(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(&Record{URL: "https://forum/", StatusCode: 200}, []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, indexFile), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"url":"https://forum/forum.php`)
+	f.Close()
+
+	records, err := store.Records()
+	if err != nil {
+		t.Fatalf("Records() error: %v", err)
+	}
+	if len(records) != 1 {
+		t.Errorf("got %d records, want 1", len(records))
+	}
+}
+
+func TestStore_DetectsCorruptObject(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := Record{URL: "https://forum/"}
+	if err := store.Put(&rec, []byte("original")); err != nil {
+		t.Fatal(err)
+	}
+
+	other := Record{URL: "https://forum/other"}
+	if err := store.Put(&other, []byte("tampered")); err != nil {
+		t.Fatal(err)
+	}
+	// Swap the object files so the first digest points at other content
+	hexSum := rec.Digest[len("sha256:"):]
+	otherSum := other.Digest[len("sha256:"):]
+	data, _ := os.ReadFile(store.objectPath(otherSum))
+	if err := os.WriteFile(store.objectPath(hexSum), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Body(rec); err == nil {
+		t.Error("expected an error for an object that does not match its digest")
+	}
+}
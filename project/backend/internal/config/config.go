@@ -0,0 +1,101 @@
+// Package config reads the server configuration from the environment.
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config is the server configuration
+type Config struct {
+	// DBDriver is the database/sql driver, postgres or sqlite3 (default sqlite3)
+	DBDriver string
+	// DatabaseURL is the connection string; SQLite defaults to forum.db in the working directory
+	DatabaseURL string
+	// Port is the HTTP port the API listens on (default 8080)
+	Port int
+	// ShutdownTimeout bounds draining requests and stopping syncs on shutdown (default 25s)
+	ShutdownTimeout time.Duration
+
+	// ForumURL is the forum the scraper syncs from (empty = sync disabled)
+	ForumURL string
+	// HotForums are the IDs of forums the scheduler keeps fresh between full syncs
+	HotForums []int
+	// ArchiveDir keeps every fetched page for reparsing (empty = no archive)
+	ArchiveDir string
+	// CacheDir keeps validators for conditional requests (empty = no cache)
+	CacheDir string
+
+	// AdminToken is the bearer token the admin endpoints require (empty = admin endpoints disabled)
+	AdminToken string
+}
+
+// Load reads the configuration from DB_DRIVER, DATABASE_URL, PORT,
+// SHUTDOWN_TIMEOUT (a duration such as 25s), FORUM_URL, HOT_FORUMS (comma
+// separated forum IDs), ARCHIVE_DIR, CACHE_DIR and ADMIN_TOKEN
+func Load() (*Config, error) {
+	cfg := &Config{
+		DBDriver:        envOr("DB_DRIVER", "sqlite3"),
+		DatabaseURL:     os.Getenv("DATABASE_URL"),
+		Port:            8080,
+		ShutdownTimeout: 25 * time.Second,
+		ForumURL:        os.Getenv("FORUM_URL"),
+		ArchiveDir:      os.Getenv("ARCHIVE_DIR"),
+		CacheDir:        os.Getenv("CACHE_DIR"),
+		AdminToken:      os.Getenv("ADMIN_TOKEN"),
+	}
+
+	switch cfg.DBDriver {
+	case "postgres":
+		if cfg.DatabaseURL == "" {
+			return nil, fmt.Errorf("invalid config: DATABASE_URL is required for postgres")
+		}
+	case "sqlite3":
+		if cfg.DatabaseURL == "" {
+			cfg.DatabaseURL = "forum.db"
+		}
+	default:
+		return nil, fmt.Errorf("invalid config: DB_DRIVER %q is not postgres or sqlite3", cfg.DBDriver)
+	}
+
+	if v := os.Getenv("PORT"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil || port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("invalid config: PORT %q is not a port number", v)
+		}
+		cfg.Port = port
+	}
+
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid config: SHUTDOWN_TIMEOUT %q is not a positive duration", v)
+		}
+		cfg.ShutdownTimeout = timeout
+	}
+
+	for _, field := range strings.Split(os.Getenv("HOT_FORUMS"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid config: HOT_FORUMS entry %q is not a forum ID", field)
+		}
+		cfg.HotForums = append(cfg.HotForums, id)
+	}
+
+	return cfg, nil
+}
+
+// envOr returns the environment variable key, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
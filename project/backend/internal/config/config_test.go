@@ -0,0 +1,84 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// setEnv sets every variable Load reads, so the test does not depend on the
+// environment it runs in
+func setEnv(t *testing.T, env map[string]string) {
+	for _, key := range []string{"DB_DRIVER", "DATABASE_URL", "PORT", "SHUTDOWN_TIMEOUT", "FORUM_URL", "HOT_FORUMS", "ARCHIVE_DIR", "CACHE_DIR", "ADMIN_TOKEN"} {
+		t.Setenv(key, env[key])
+	}
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	setEnv(t, nil)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := &Config{DBDriver: "sqlite3", DatabaseURL: "forum.db", Port: 8080, ShutdownTimeout: 25 * time.Second}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Expected %+v, got %+v", want, cfg)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	setEnv(t, map[string]string{
+		"DB_DRIVER":        "postgres",
+		"DATABASE_URL":     "postgres://forum_user@postgres:5432/forum_db",
+		"PORT":             "9090",
+		"SHUTDOWN_TIMEOUT": "1m",
+		"FORUM_URL":        "https://www.resql.ru/forum",
+		"HOT_FORUMS":       "1, 4,",
+		"ARCHIVE_DIR":      "/data/archive",
+		"ADMIN_TOKEN":      "s3cret",
+	})
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := &Config{
+		DBDriver:        "postgres",
+		DatabaseURL:     "postgres://forum_user@postgres:5432/forum_db",
+		Port:            9090,
+		ShutdownTimeout: time.Minute,
+		ForumURL:        "https://www.resql.ru/forum",
+		HotForums:       []int{1, 4},
+		ArchiveDir:      "/data/archive",
+		AdminToken:      "s3cret",
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Expected %+v, got %+v", want, cfg)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"unknown driver", map[string]string{"DB_DRIVER": "mysql"}},
+		{"postgres without url", map[string]string{"DB_DRIVER": "postgres"}},
+		{"port not a number", map[string]string{"PORT": "http"}},
+		{"port out of range", map[string]string{"PORT": "70000"}},
+		{"timeout without unit", map[string]string{"SHUTDOWN_TIMEOUT": "30"}},
+		{"hot forum not an ID", map[string]string{"HOT_FORUMS": "1,mssql"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnv(t, tt.env)
+			if cfg, err := Load(); err == nil {
+				t.Errorf("Expected error, got %+v", cfg)
+			}
+		})
+	}
+}
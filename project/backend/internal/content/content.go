@@ -0,0 +1,268 @@
+package content
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Formats a post body can be served in
+const (
+	HTML     = "html"
+	Markdown = "markdown"
+	Text     = "text"
+)
+
+// ValidFormat reports whether format names one of the content formats
+func ValidFormat(format string) bool {
+	return format == HTML || format == Markdown || format == Text
+}
+
+// The converters below read the sanitized HTML subset the scraper stores for
+// posts: inline formatting, links, lists, line breaks, <blockquote> quotes with
+// a <cite> author and <pre><code> blocks.
+
+// ToMarkdown converts stored post HTML to Markdown for exports
+func ToMarkdown(body string) string {
+	var w mdWriter
+	for _, n := range parseFragment(body) {
+		w.node(n)
+	}
+	return tidy(w.b.String())
+}
+
+// ToText converts stored post HTML to plain text for search: markup is dropped,
+// line breaks and block ends become newlines and entities are decoded
+func ToText(body string) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			text := n.Data
+			if b.Len() == 0 || strings.HasSuffix(b.String(), "\n") {
+				if n.Parent == nil || n.Parent.DataAtom != atom.Code {
+					text = strings.TrimLeft(text, " ")
+				}
+			}
+			b.WriteString(text)
+		case n.Type != html.ElementNode:
+		case n.DataAtom == atom.Br:
+			b.WriteByte('\n')
+		case n.DataAtom == atom.Cite:
+			walkChildren(n, walk)
+			b.WriteString(":\n")
+			return
+		}
+		walkChildren(n, walk)
+		if n.Type == html.ElementNode && isBlock(n) {
+			b.WriteByte('\n')
+		}
+	}
+	for _, n := range parseFragment(body) {
+		walk(n)
+	}
+	return tidy(b.String())
+}
+
+// parseFragment parses stored post HTML as the content of a <div>
+func parseFragment(body string) []*html.Node {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(body), context)
+	if err != nil {
+		// The reader cannot fail, so neither can parsing
+		return nil
+	}
+	return nodes
+}
+
+func walkChildren(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		fn(c)
+	}
+}
+
+func isBlock(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.P, atom.Li, atom.Blockquote, atom.Pre, atom.Ul, atom.Ol:
+		return true
+	}
+	return false
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// tidy trims trailing spaces from lines, collapses runs of blank lines and
+// trims the result
+func tidy(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		// A Markdown hard break is two trailing spaces; keep those that
+		// precede more text
+		hardBreak := strings.HasSuffix(line, "  ") && i+1 < len(lines) && strings.TrimSpace(lines[i+1]) != ""
+		if !hardBreak || strings.TrimSpace(line) == "" {
+			lines[i] = strings.TrimRight(line, " \t")
+		}
+	}
+	s = blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimRight(strings.TrimLeft(s, "\n"), " \n")
+}
+
+// mdWriter renders nodes as Markdown
+type mdWriter struct {
+	b strings.Builder
+	// list is the stack of open lists; an entry counts the items of an ordered
+	// list and is -1 for an unordered one
+	list []int
+}
+
+var mdSpecial = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`,
+)
+
+func (w *mdWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *mdWriter) wrap(n *html.Node, mark string) {
+	w.b.WriteString(mark)
+	w.children(n)
+	w.b.WriteString(mark)
+}
+
+func (w *mdWriter) node(n *html.Node) {
+	if n.Type == html.TextNode {
+		w.b.WriteString(mdSpecial.Replace(n.Data))
+		return
+	}
+	if n.Type != html.ElementNode {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Strong, atom.B:
+		w.wrap(n, "**")
+	case atom.Em, atom.I:
+		w.wrap(n, "*")
+	case atom.S, atom.Del:
+		w.wrap(n, "~~")
+	case atom.Br:
+		w.b.WriteString("  \n")
+	case atom.A:
+		w.link(n)
+	case atom.P:
+		w.b.WriteString("\n\n")
+		w.children(n)
+		w.b.WriteString("\n\n")
+	case atom.Ul, atom.Ol:
+		kind := -1
+		if n.DataAtom == atom.Ol {
+			kind = 0
+		}
+		w.list = append(w.list, kind)
+		w.b.WriteString("\n\n")
+		w.children(n)
+		w.b.WriteString("\n")
+		w.list = w.list[:len(w.list)-1]
+	case atom.Li:
+		w.item(n)
+	case atom.Pre:
+		w.code(n)
+	case atom.Blockquote:
+		w.quote(n)
+	case atom.Cite:
+		w.wrap(n, "**")
+		w.b.WriteString(":  \n")
+	default:
+		w.children(n)
+	}
+}
+
+func (w *mdWriter) link(n *html.Node) {
+	href := attr(n, "href")
+	var inner mdWriter
+	inner.children(n)
+	text := inner.b.String()
+	if href == "" {
+		w.b.WriteString(text)
+		return
+	}
+	if text == mdSpecial.Replace(href) {
+		w.b.WriteString("<" + href + ">")
+		return
+	}
+	w.b.WriteString("[" + text + "](" + strings.ReplaceAll(href, ")", "%29") + ")")
+}
+
+func (w *mdWriter) item(n *html.Node) {
+	marker := "- "
+	depth := len(w.list)
+	if depth > 0 && w.list[depth-1] >= 0 {
+		w.list[depth-1]++
+		marker = strconv.Itoa(w.list[depth-1]) + ". "
+	}
+	var inner mdWriter
+	inner.list = w.list
+	inner.children(n)
+	indent := strings.Repeat("   ", max(depth-1, 0))
+	lines := strings.Split(strings.Trim(inner.b.String(), "\n"), "\n")
+	w.b.WriteString(indent + marker + lines[0] + "\n")
+	for _, line := range lines[1:] {
+		w.b.WriteString(indent + strings.Repeat(" ", len(marker)) + line + "\n")
+	}
+}
+
+func (w *mdWriter) code(n *html.Node) {
+	lang := ""
+	if code := n.FirstChild; code != nil && code.DataAtom == atom.Code {
+		lang = strings.TrimPrefix(attr(code, "class"), "language-")
+	}
+	text := textOf(n)
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	w.b.WriteString("\n\n" + fence + lang + "\n" + text + "\n" + fence + "\n\n")
+}
+
+func (w *mdWriter) quote(n *html.Node) {
+	var inner mdWriter
+	inner.children(n)
+	w.b.WriteString("\n\n")
+	for _, line := range strings.Split(tidy(inner.b.String()), "\n") {
+		if line == "" {
+			w.b.WriteString(">\n")
+			continue
+		}
+		w.b.WriteString("> " + line + "\n")
+	}
+	w.b.WriteString("\n")
+}
+
+// textOf returns the text of n and its descendants verbatim
+func textOf(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			b.WriteString(node.Data)
+		}
+		walkChildren(node, walk)
+	}
+	walk(n)
+	return b.String()
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package content
+
+import "testing"
+
+func TestToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "Добрый день!", "Добрый день!"},
+		{"line breaks", "Первая<br>Вторая", "Первая  \nВторая"},
+		{"inline formatting", "Как <strong>ускорить</strong> <em>MERGE</em>?", "Как **ускорить** *MERGE*?"},
+		{"entities and specials", "a &lt;&gt; b * c_d", `a \<> b \* c\_d`},
+		{"link", `См. <a href="https://learn.microsoft.com/sql">документацию</a>.`, "См. [документацию](https://learn.microsoft.com/sql)."},
+		{"bare link", `<a href="https://example.com">https://example.com</a>`, "<https://example.com>"},
+		{
+			"code block",
+			`Так:<br><pre><code class="language-sql">SELECT *
+FROM t</code></pre>`,
+			"Так:\n\n```sql\nSELECT *\nFROM t\n```",
+		},
+		{
+			"nested quote",
+			"<blockquote><cite>aleks2</cite><blockquote><cite>ivanov</cite>MERGE идёт час</blockquote>Бейте на пачки</blockquote>Спасибо",
+			"> **aleks2**:\n>\n> > **ivanov**:  \n> > MERGE идёт час\n>\n> Бейте на пачки\n\nСпасибо",
+		},
+		{"lists", "<ul><li>one</li><li>two</li></ul><ol><li>first</li><li>second</li></ol>", "- one\n- two\n\n1. first\n2. second"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToMarkdown(tt.in); got != tt.want {
+				t.Errorf("ToMarkdown(%q) =\n%q\nwant\n%q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"markup dropped", "Как <strong>ускорить</strong>?", "Как ускорить?"},
+		{"line breaks", "Первая<br>Вторая", "Первая\nВторая"},
+		{"entities decoded", "a &lt;&gt; b &amp; c", "a <> b & c"},
+		{"link text kept", `См. <a href="https://learn.microsoft.com/sql">документацию</a>.`, "См. документацию."},
+		{"quote", "<blockquote><cite>aleks2</cite>Бейте на пачки</blockquote>Спасибо", "aleks2:\nБейте на пачки\nСпасибо"},
+		{"code", `<pre><code class="language-sql">SELECT 1</code></pre>`, "SELECT 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToText(tt.in); got != tt.want {
+				t.Errorf("ToText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package diff
+
+// Op is the kind of change a diff line describes
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Line is one line of a diff
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// maxCells bounds the work of the LCS table; larger inputs are diffed as a
+// whole-block replacement instead
+const maxCells = 4_000_000
+
+// Lines returns the line diff that turns a into b, built from a longest common
+// subsequence. Deletions are listed before insertions within a changed block.
+func Lines(a, b []string) []Line {
+	// Common prefix and suffix need no table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var out []Line
+	for _, line := range a[:prefix] {
+		out = append(out, Line{Op: Equal, Text: line})
+	}
+	out = append(out, middle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		out = append(out, Line{Op: Equal, Text: line})
+	}
+	return out
+}
+
+// middle diffs the part of the inputs between their common prefix and suffix
+func middle(a, b []string) []Line {
+	if len(a)*len(b) > maxCells {
+		return replace(a, b)
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []Line
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, Line{Op: Equal, Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, Line{Op: Delete, Text: a[i]})
+			i++
+		default:
+			out = append(out, Line{Op: Insert, Text: b[j]})
+			j++
+		}
+	}
+	return append(out, replace(a[i:], b[j:])...)
+}
+
+// replace diffs a into b as all of a deleted followed by all of b inserted
+func replace(a, b []string) []Line {
+	out := make([]Line, 0, len(a)+len(b))
+	for _, line := range a {
+		out = append(out, Line{Op: Delete, Text: line})
+	}
+	for _, line := range b {
+		out = append(out, Line{Op: Insert, Text: line})
+	}
+	return out
+}
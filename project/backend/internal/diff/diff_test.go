@@ -0,0 +1,67 @@
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{
+			name: "identical",
+			a:    "one\ntwo",
+			b:    "one\ntwo",
+			want: []Line{{Equal, "one"}, {Equal, "two"}},
+		},
+		{
+			name: "changed line",
+			a:    "select *\nfrom t\nwhere id = 1",
+			b:    "select *\nfrom t\nwhere id = 2",
+			want: []Line{{Equal, "select *"}, {Equal, "from t"}, {Delete, "where id = 1"}, {Insert, "where id = 2"}},
+		},
+		{
+			name: "inserted and removed lines",
+			a:    "a\nb\nc\nd",
+			b:    "a\nc\nd\ne",
+			want: []Line{{Equal, "a"}, {Delete, "b"}, {Equal, "c"}, {Equal, "d"}, {Insert, "e"}},
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "new",
+			want: []Line{{Delete, ""}, {Insert, "new"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Lines(strings.Split(tt.a, "\n"), strings.Split(tt.b, "\n"))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lines() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLines_ReconstructsBothSides(t *testing.T) {
+	a := strings.Split("x\ny\nz\ny\nx\nw", "\n")
+	b := strings.Split("y\nx\nz\nw\nx", "\n")
+
+	var gotA, gotB []string
+	for _, line := range Lines(a, b) {
+		if line.Op != Insert {
+			gotA = append(gotA, line.Text)
+		}
+		if line.Op != Delete {
+			gotB = append(gotB, line.Text)
+		}
+	}
+	if !reflect.DeepEqual(gotA, a) || !reflect.DeepEqual(gotB, b) {
+		t.Errorf("diff does not reconstruct its inputs: got %v / %v", gotA, gotB)
+	}
+}
@@ -0,0 +1,310 @@
+// Package migrate keeps the database schema at the version the code expects.
+//
+// Migrations are numbered pairs of SQL files, NNNN_name.up.sql and
+// NNNN_name.down.sql, written once per dialect under migrations/<driver>.
+// Both dialects define the same versions, so Postgres in production and SQLite
+// in development and tests are built by the same steps. Applied versions are
+// recorded in the schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationFile matches the name of a migration file
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// lockStatements open the transaction a migration run holds for its duration,
+// so concurrent instances migrate one after the other. Postgres takes an
+// advisory lock released at commit; SQLite takes the database write lock.
+var lockStatements = map[string][]string{
+	"postgres": {"BEGIN", "SELECT pg_advisory_xact_lock(7265746571)"},
+	"sqlite3":  {"BEGIN IMMEDIATE"},
+}
+
+// migrationsTableExists queries whether schema_migrations exists, by driver
+var migrationsTableExists = map[string]string{
+	"postgres": "SELECT to_regclass('schema_migrations') IS NOT NULL",
+	"sqlite3":  "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'",
+}
+
+const createMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)
+`
+
+// Migration is one step of the schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and when it was applied (nil = pending)
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Migrator applies and rolls back the migrations of one database
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for db, opened with driver (postgres or sqlite3)
+func NewMigrator(db *sql.DB, driver string) (*Migrator, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// Load reads the migrations of driver, ordered by version
+func Load(driver string) ([]Migration, error) {
+	if _, ok := lockStatements[driver]; !ok {
+		return nil, fmt.Errorf("no migrations for driver %q", driver)
+	}
+
+	dir := "migrations/" + driver
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		m := migrationFile.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("unexpected migration file %s/%s", dir, entry.Name())
+		}
+		body, err := fs.ReadFile(migrationFiles, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		version, _ := strconv.Atoi(m[1])
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in version order and returns those applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, done map[int]appliedMigration) error {
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if _, err := conn.ExecContext(ctx, migration.Up); err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			_, err := conn.ExecContext(ctx,
+				"INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)",
+				migration.Version, migration.Name, time.Now().UTC(),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Down rolls back the most recently applied migration and returns it, or nil
+// when none is applied
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var rolledBack *Migration
+	err := m.locked(ctx, func(conn *sql.Conn, done map[int]appliedMigration) error {
+		latest := -1
+		for version := range done {
+			if version > latest {
+				latest = version
+			}
+		}
+		if latest < 0 {
+			return nil
+		}
+
+		migration := m.find(latest)
+		if migration == nil {
+			return fmt.Errorf("migration %d_%s is not known to this build", latest, done[latest].name)
+		}
+		if _, err := conn.ExecContext(ctx, migration.Down); err != nil {
+			return fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if _, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version); err != nil {
+			return fmt.Errorf("failed to unrecord migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		rolledBack = migration
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rolledBack, nil
+}
+
+// Status lists every known migration with when it was applied, followed by
+// applied migrations this build does not know. It only reads schema_migrations,
+// without taking the migration lock, so it neither waits for a running
+// migration nor holds one up.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a database connection: %w", err)
+	}
+	defer conn.Close()
+
+	// A database never migrated has no schema_migrations yet
+	done := make(map[int]appliedMigration)
+	var exists bool
+	if err := conn.QueryRowContext(ctx, migrationsTableExists[m.driver]).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to look up schema_migrations: %w", err)
+	}
+	if exists {
+		if done, err = appliedMigrations(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if a, ok := done[migration.Version]; ok {
+			status.AppliedAt = &a.appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	var unknown []Status
+	for version, a := range done {
+		if m.find(version) == nil {
+			unknown = append(unknown, Status{Version: version, Name: a.name, AppliedAt: &a.appliedAt})
+		}
+	}
+	sort.Slice(unknown, func(i, j int) bool { return unknown[i].Version < unknown[j].Version })
+	return append(statuses, unknown...), nil
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	name      string
+	appliedAt time.Time
+}
+
+// locked runs fn in a transaction on a single connection holding the
+// migration lock, passing it the applied migrations by version. The
+// transaction is committed if fn succeeds and rolled back otherwise.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, done map[int]appliedMigration) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a database connection: %w", err)
+	}
+	defer conn.Close()
+
+	for _, stmt := range lockStatements[m.driver] {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK")
+			return fmt.Errorf("failed to lock migrations: %w", err)
+		}
+	}
+
+	err = func() error {
+		if _, err := conn.ExecContext(ctx, createMigrationsTable); err != nil {
+			return fmt.Errorf("failed to create schema_migrations: %w", err)
+		}
+		done, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return fn(conn, done)
+	}()
+	if err != nil {
+		if _, rbErr := conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK"); rbErr != nil {
+			log.Printf("migrate: failed to roll back: %v", rbErr)
+		}
+		return err
+	}
+
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return fmt.Errorf("failed to commit migrations: %w", err)
+	}
+	return nil
+}
+
+// appliedMigrations reads schema_migrations
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[int]appliedMigration)
+	for rows.Next() {
+		var version int
+		var a appliedMigration
+		if err := rows.Scan(&version, &a.name, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		done[version] = a
+	}
+	return done, rows.Err()
+}
+
+// find returns the known migration with version, or nil
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
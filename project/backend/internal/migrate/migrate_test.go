@@ -0,0 +1,233 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openTestDB(t *testing.T, dsn string) *sql.DB {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = $1", name).Scan(&n)
+	if err != nil {
+		t.Fatalf("Failed to look up table %s: %v", name, err)
+	}
+	return n > 0
+}
+
+func TestLoad_DialectsDefineSameMigrations(t *testing.T) {
+	postgres, err := Load("postgres")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	sqlite, err := Load("sqlite3")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(postgres) == 0 || len(postgres) != len(sqlite) {
+		t.Fatalf("Expected the same migrations in both dialects, got %d and %d", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Errorf("Migration %d differs: %d_%s and %d_%s", i,
+				postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+		if postgres[i].Version != i+1 {
+			t.Errorf("Expected migration %d to have version %d, got %d", i, i+1, postgres[i].Version)
+		}
+	}
+
+	if _, err := Load("mysql"); err == nil {
+		t.Error("Expected error for unknown driver")
+	}
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	db := openTestDB(t, ":memory:")
+	db.SetMaxOpenConns(1)
+	m, err := NewMigrator(db, "sqlite3")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx := context.Background()
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(applied) != len(m.migrations) {
+		t.Errorf("Expected %d migrations applied, got %d", len(m.migrations), len(applied))
+	}
+	for _, table := range []string{"forums", "topics", "posts", "users", "post_links", "sync_jobs", "counter_drift"} {
+		if !tableExists(t, db, table) {
+			t.Errorf("Expected table %s to exist", table)
+		}
+	}
+
+	applied, err = m.Up(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("Expected a second Up to apply nothing, got %v, %v", applied, err)
+	}
+
+	latest := m.migrations[len(m.migrations)-1]
+	rolledBack, err := m.Down(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rolledBack == nil || rolledBack.Version != latest.Version {
+		t.Errorf("Expected migration %d rolled back, got %+v", latest.Version, rolledBack)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(statuses) != len(m.migrations) {
+		t.Fatalf("Expected %d statuses, got %d", len(m.migrations), len(statuses))
+	}
+	if statuses[0].AppliedAt == nil || statuses[len(statuses)-1].AppliedAt != nil {
+		t.Errorf("Expected every migration but the last applied, got %+v", statuses)
+	}
+
+	for range m.migrations {
+		if _, err := m.Down(ctx); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if tableExists(t, db, "forums") {
+		t.Error("Expected forums to be dropped")
+	}
+	rolledBack, err = m.Down(ctx)
+	if err != nil || rolledBack != nil {
+		t.Errorf("Expected Down with nothing applied to do nothing, got %+v, %v", rolledBack, err)
+	}
+}
+
+func TestMigrator_UnknownAppliedMigration(t *testing.T) {
+	db := openTestDB(t, ":memory:")
+	db.SetMaxOpenConns(1)
+	m, err := NewMigrator(db, "sqlite3")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx := context.Background()
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A newer build applied a migration this one does not have
+	_, err = db.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (999, 'future', CURRENT_TIMESTAMP)")
+	if err != nil {
+		t.Fatalf("Failed to record migration: %v", err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if last := statuses[len(statuses)-1]; last.Version != 999 || last.Name != "future" {
+		t.Errorf("Expected the unknown migration listed last, got %+v", last)
+	}
+
+	if _, err := m.Down(ctx); err == nil {
+		t.Error("Expected error rolling back an unknown migration")
+	}
+}
+
+func TestMigrator_ConcurrentUp(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "forum.db")
+
+	var wg sync.WaitGroup
+	applied := make([]int, 4)
+	errs := make([]error, 4)
+	for i := range applied {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Each instance has its own connection pool, like separate processes
+			m, err := NewMigrator(openTestDB(t, dsn), "sqlite3")
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			migrations, err := m.Up(context.Background())
+			applied[i], errs[i] = len(migrations), err
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range applied {
+		if errs[i] != nil {
+			t.Errorf("Instance %d failed: %v", i, errs[i])
+		}
+		total += applied[i]
+	}
+
+	migrations, _ := Load("sqlite3")
+	if total != len(migrations) {
+		t.Errorf("Expected every migration applied exactly once, got %d applications of %d", total, len(migrations))
+	}
+}
+
+func TestMigrator_StatusDoesNotLock(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "forum.db")
+	m, err := NewMigrator(openTestDB(t, dsn), "sqlite3")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	ctx := context.Background()
+
+	// A database never migrated lists every migration pending and is not written to
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(statuses) != len(m.migrations) || statuses[0].AppliedAt != nil {
+		t.Errorf("Expected every migration pending, got %+v", statuses)
+	}
+	if tableExists(t, m.db, "schema_migrations") {
+		t.Error("Expected Status not to create schema_migrations")
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Another instance holding the migration lock does not block Status
+	other := openTestDB(t, dsn+"?_busy_timeout=0")
+	conn, err := other.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get a connection: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		t.Fatalf("Failed to lock: %v", err)
+	}
+	defer conn.ExecContext(ctx, "ROLLBACK")
+
+	statusCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	statuses, err = m.Status(statusCtx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if statuses[len(statuses)-1].AppliedAt == nil {
+		t.Errorf("Expected every migration applied, got %+v", statuses)
+	}
+}
@@ -0,0 +1,5 @@
+DROP TABLE post_revisions;
+DROP TABLE posts;
+DROP TABLE topics;
+DROP TABLE users;
+DROP TABLE forums;
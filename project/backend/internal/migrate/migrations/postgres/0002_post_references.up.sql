@@ -0,0 +1,18 @@
+-- Quotes and links between posts, kept in the order they appear in a post
+CREATE TABLE post_quotes (
+	post_id INTEGER NOT NULL REFERENCES posts(id),
+	quoted_post_id INTEGER NOT NULL,
+	position INTEGER NOT NULL,
+	PRIMARY KEY (post_id, quoted_post_id)
+);
+CREATE INDEX idx_post_quotes_quoted_post_id ON post_quotes(quoted_post_id);
+
+CREATE TABLE post_links (
+	post_id INTEGER NOT NULL REFERENCES posts(id),
+	position INTEGER NOT NULL,
+	url TEXT NOT NULL,
+	target_topic_id INTEGER,
+	target_post_id INTEGER,
+	PRIMARY KEY (post_id, position)
+);
+CREATE INDEX idx_post_links_target_topic_id ON post_links(target_topic_id);
@@ -0,0 +1,3 @@
+DROP TABLE parse_quarantine;
+DROP TABLE counter_drift;
+DROP TABLE sync_jobs;
@@ -0,0 +1,36 @@
+-- Sync runs, the counters they corrected and the pages they could not parse
+CREATE TABLE sync_jobs (
+	id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	scope TEXT NOT NULL,
+	target_id INTEGER,
+	trigger TEXT NOT NULL,
+	status TEXT NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL,
+	finished_at TIMESTAMPTZ,
+	pages_fetched INTEGER DEFAULT 0,
+	rows_upserted INTEGER DEFAULT 0,
+	counters_corrected INTEGER DEFAULT 0,
+	errors TEXT
+);
+
+CREATE TABLE counter_drift (
+	id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	job_id INTEGER NOT NULL REFERENCES sync_jobs(id),
+	entity TEXT NOT NULL,
+	entity_id INTEGER NOT NULL,
+	counter TEXT NOT NULL,
+	stored INTEGER NOT NULL,
+	actual INTEGER NOT NULL
+);
+CREATE INDEX idx_counter_drift_job_id ON counter_drift(job_id);
+
+CREATE TABLE parse_quarantine (
+	id INTEGER GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+	url TEXT NOT NULL,
+	path TEXT NOT NULL UNIQUE,
+	body_ref TEXT,
+	error TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 1,
+	first_failed_at TIMESTAMPTZ NOT NULL,
+	last_failed_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP INDEX idx_users_username;
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (username);
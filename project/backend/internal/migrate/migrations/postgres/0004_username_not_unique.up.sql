@@ -0,0 +1,4 @@
+-- Usernames identify nobody: users are keyed by their forum ID, guests share
+-- one placeholder row and a renamed user's old name can be taken by another
+ALTER TABLE users DROP CONSTRAINT users_username_key;
+CREATE INDEX idx_users_username ON users(username);
@@ -0,0 +1 @@
+ALTER TABLE parse_quarantine DROP COLUMN body;
@@ -0,0 +1,3 @@
+-- Without an archive the quarantine keeps the page itself, so a failure can
+-- still be inspected
+ALTER TABLE parse_quarantine ADD COLUMN body TEXT;
@@ -0,0 +1,7 @@
+-- The counts the forum lists are kept apart from the counters reconciled from
+-- the stored rows, so neither overwrites the other. They are filled by the
+-- next sync of the forum, listing or profile.
+ALTER TABLE forums ADD COLUMN upstream_topic_count INTEGER DEFAULT 0;
+ALTER TABLE forums ADD COLUMN upstream_post_count INTEGER DEFAULT 0;
+ALTER TABLE topics ADD COLUMN upstream_reply_count INTEGER DEFAULT 0;
+ALTER TABLE users ADD COLUMN upstream_post_count INTEGER DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN search_text;
+ALTER TABLE posts DROP COLUMN search_text;
+ALTER TABLE topics DROP COLUMN search_text;
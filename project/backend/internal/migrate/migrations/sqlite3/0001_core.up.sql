@@ -0,0 +1,65 @@
+-- Forums, topics, posts and users are keyed by their IDs on the forum
+CREATE TABLE forums (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	description TEXT,
+	topic_count INTEGER DEFAULT 0,
+	post_count INTEGER DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT NOT NULL UNIQUE,
+	post_count INTEGER DEFAULT 0,
+	topic_count INTEGER DEFAULT 0,
+	registered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	last_active_at DATETIME,
+	location TEXT,
+	rank TEXT,
+	profile_synced_at DATETIME
+);
+
+CREATE TABLE topics (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	title TEXT NOT NULL,
+	-- A topic synced on its own may arrive before the forum index
+	forum_id INTEGER NOT NULL,
+	author_id INTEGER NOT NULL REFERENCES users(id),
+	reply_count INTEGER DEFAULT 0,
+	view_count INTEGER DEFAULT 0,
+	last_post_id INTEGER,
+	last_post_at DATETIME,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_topics_forum_id ON topics(forum_id);
+CREATE INDEX idx_topics_author_id ON topics(author_id);
+CREATE INDEX idx_topics_last_post_at ON topics(last_post_at);
+
+CREATE TABLE posts (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	topic_id INTEGER NOT NULL REFERENCES topics(id),
+	author_id INTEGER NOT NULL REFERENCES users(id),
+	content TEXT NOT NULL,
+	content_hash TEXT,
+	content_markdown TEXT,
+	content_text TEXT,
+	is_first_post INTEGER DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	deleted_at DATETIME
+);
+CREATE INDEX idx_posts_topic_id ON posts(topic_id, created_at);
+CREATE INDEX idx_posts_author_id ON posts(author_id);
+
+CREATE TABLE post_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	post_id INTEGER NOT NULL REFERENCES posts(id),
+	content TEXT NOT NULL,
+	content_hash TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	replaced_at DATETIME NOT NULL
+);
+CREATE INDEX idx_post_revisions_post_id ON post_revisions(post_id);
@@ -0,0 +1,14 @@
+CREATE TABLE users_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT NOT NULL UNIQUE,
+	post_count INTEGER DEFAULT 0,
+	topic_count INTEGER DEFAULT 0,
+	registered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	last_active_at DATETIME,
+	location TEXT,
+	rank TEXT,
+	profile_synced_at DATETIME
+);
+INSERT INTO users_new SELECT id, username, post_count, topic_count, registered_at, last_active_at, location, rank, profile_synced_at FROM users;
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
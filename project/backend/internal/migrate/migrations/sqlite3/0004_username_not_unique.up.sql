@@ -0,0 +1,19 @@
+-- Usernames identify nobody: users are keyed by their forum ID, guests share
+-- one placeholder row and a renamed user's old name can be taken by another.
+-- SQLite cannot drop a constraint, so the table is rebuilt; topics and posts
+-- refer to it by name and follow the rename.
+CREATE TABLE users_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT NOT NULL,
+	post_count INTEGER DEFAULT 0,
+	topic_count INTEGER DEFAULT 0,
+	registered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	last_active_at DATETIME,
+	location TEXT,
+	rank TEXT,
+	profile_synced_at DATETIME
+);
+INSERT INTO users_new SELECT id, username, post_count, topic_count, registered_at, last_active_at, location, rank, profile_synced_at FROM users;
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+CREATE INDEX idx_users_username ON users(username);
@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN upstream_post_count;
+ALTER TABLE topics DROP COLUMN upstream_reply_count;
+ALTER TABLE forums DROP COLUMN upstream_post_count;
+ALTER TABLE forums DROP COLUMN upstream_topic_count;
@@ -9,6 +9,10 @@ type Forum struct {
 	Description string    `json:"description" db:"description"`
 	TopicCount  int       `json:"topicCount" db:"topic_count"`
 	PostCount int       `json:"postCount" db:"post_count"`
+	// The upstream counts are those listed on the forum index, which cover
+	// more than is mirrored; the counts above are kept to the stored rows
+	UpstreamTopicCount int       `json:"upstreamTopicCount" db:"upstream_topic_count"`
+	UpstreamPostCount  int       `json:"upstreamPostCount" db:"upstream_post_count"`
 	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
 }
@@ -22,6 +26,8 @@ type Topic struct {
 	AuthorID    int       `json:"authorId" db:"author_id"`
 	AuthorName  string    `json:"authorName" db:"author_name"`
 	ReplyCount  int       `json:"replyCount" db:"reply_count"`
+	// UpstreamReplyCount is the reply count listed on the forum
+	UpstreamReplyCount int `json:"upstreamReplyCount" db:"upstream_reply_count"`
 	ViewCount   int       `json:"viewCount" db:"view_count"`
 	LastPostID  *int      `json:"lastPostId,omitempty" db:"last_post_id"`
 	LastPostAt  *time.Time `json:"lastPostAt,omitempty" db:"last_post_at"`
@@ -36,10 +42,61 @@ type Post struct {
 	TopicTitle  string    `json:"topicTitle" db:"topic_title"`
 	AuthorID    int       `json:"authorId" db:"author_id"`
 	AuthorName  string    `json:"authorName" db:"author_name"`
-	Content     string    `json:"content" db:"content"`
+	// Content is sanitized HTML; ContentMarkdown and ContentText are the same body
+	// as Markdown and plain text, served in its place on request
+	Content         string `json:"content" db:"content"`
+	ContentMarkdown string `json:"-" db:"content_markdown"`
+	ContentText     string `json:"-" db:"content_text"`
 	IsFirstPost bool      `json:"isFirstPost" db:"is_first_post"`
 	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+	// DeletedAt is set once the post has disappeared from the forum
+	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	// Quotes are the quotes found in the post by the scraper, in order
+	Quotes []PostQuote `json:"-" db:"-"`
+}
+
+// PostQuote is a quote of an earlier post. QuotedPostID is 0 while only the
+// quoted author is known; such quotes are resolved within the topic when stored.
+type PostQuote struct {
+	PostID       int    `json:"postId" db:"post_id"`
+	QuotedPostID int    `json:"quotedPostId" db:"quoted_post_id"`
+	QuotedAuthor string `json:"quotedAuthor" db:"-"`
+}
+
+// PostLink is a link in a post. Links to forum topics carry the upstream ID of
+// the linked topic, and of the post for links to a single post, which are also
+// the local IDs once that topic is mirrored.
+type PostLink struct {
+	PostID        int    `json:"postId" db:"post_id"`
+	URL           string `json:"url" db:"url"`
+	TargetTopicID *int   `json:"targetTopicId,omitempty" db:"target_topic_id"`
+	TargetPostID  *int   `json:"targetPostId,omitempty" db:"target_post_id"`
+}
+
+// TopicLink is a link from a post of one topic to another topic
+type TopicLink struct {
+	SourcePostID     int       `json:"sourcePostId"`
+	SourceTopicID    int       `json:"sourceTopicId"`
+	SourceTopicTitle string    `json:"sourceTopicTitle"`
+	URL              string    `json:"url"`
+	TargetTopicID    int       `json:"targetTopicId"`
+	TargetPostID     *int      `json:"targetPostId,omitempty"`
+	// TargetTopicTitle is set when the target topic is mirrored locally
+	TargetTopicTitle *string   `json:"targetTopicTitle,omitempty"`
+	Mirrored         bool      `json:"mirrored"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// PostRevision is an earlier version of a post that was edited upstream
+type PostRevision struct {
+	ID          int       `json:"id" db:"id"`
+	PostID      int       `json:"postId" db:"post_id"`
+	Content     string    `json:"content" db:"content"`
+	ContentHash string    `json:"contentHash" db:"content_hash"`
+	// CreatedAt is when this version was first stored, ReplacedAt when a newer one replaced it
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	ReplacedAt time.Time `json:"replacedAt" db:"replaced_at"`
 }
 
 // User represents a forum user
@@ -48,8 +105,73 @@ type User struct {
 	Username    string     `json:"username" db:"username"`
 	PostCount   int        `json:"postCount" db:"post_count"`
 	TopicCount  int        `json:"topicCount" db:"topic_count"`
+	// UpstreamPostCount is the post total on the profile page, including posts
+	// that are not mirrored
+	UpstreamPostCount int  `json:"upstreamPostCount" db:"upstream_post_count"`
 	RegisteredAt time.Time `json:"registeredAt" db:"registered_at"`
 	LastActiveAt *time.Time `json:"lastActiveAt,omitempty" db:"last_active_at"`
+	Location     string     `json:"location,omitempty" db:"location"`
+	Rank         string     `json:"rank,omitempty" db:"rank"`
+	// ProfileSyncedAt is when the profile page was last scraped (nil = never)
+	ProfileSyncedAt *time.Time `json:"profileSyncedAt,omitempty" db:"profile_synced_at"`
+}
+
+// Sync job statuses
+const (
+	SyncJobRunning   = "running"
+	SyncJobSucceeded = "succeeded"
+	SyncJobFailed    = "failed"
+)
+
+// SyncJob represents a single run of the forum sync
+type SyncJob struct {
+	ID           int        `json:"id" db:"id"`
+	Scope        string     `json:"scope" db:"scope"`
+	TargetID     *int       `json:"targetId,omitempty" db:"target_id"`
+	Trigger      string     `json:"trigger" db:"trigger"`
+	Status       string     `json:"status" db:"status"`
+	StartedAt    time.Time  `json:"startedAt" db:"started_at"`
+	FinishedAt   *time.Time `json:"finishedAt,omitempty" db:"finished_at"`
+	PagesFetched int        `json:"pagesFetched" db:"pages_fetched"`
+	RowsUpserted int        `json:"rowsUpserted" db:"rows_upserted"`
+	// CountersCorrected is how many stored counters the job found drifted and fixed
+	CountersCorrected int      `json:"countersCorrected" db:"counters_corrected"`
+	Errors            []string `json:"errors" db:"errors"`
+}
+
+// Counter drift entities
+const (
+	CounterEntityForum = "forum"
+	CounterEntityTopic = "topic"
+	CounterEntityUser  = "user"
+)
+
+// CounterDrift is a stored counter that did not match the rows it counts and
+// was corrected
+type CounterDrift struct {
+	JobID    int    `json:"jobId" db:"job_id"`
+	Entity   string `json:"entity" db:"entity"`
+	EntityID int    `json:"entityId" db:"entity_id"`
+	Counter  string `json:"counter" db:"counter"`
+	Stored   int    `json:"stored" db:"stored"`
+	Actual   int    `json:"actual" db:"actual"`
+}
+
+// QuarantinedPage is a fetched page the scraper could not parse
+type QuarantinedPage struct {
+	ID int `json:"id" db:"id"`
+	// URL is where the page was fetched from, Path the page path to fetch it again with
+	URL  string `json:"url" db:"url"`
+	Path string `json:"path" db:"path"`
+	// BodyRef is the archive digest of the raw page (empty when archiving is off)
+	BodyRef string `json:"bodyRef,omitempty" db:"body_ref"`
+	// Body is the page decoded to UTF-8, kept instead of BodyRef when archiving
+	// is off; it is only loaded for a single page
+	Body          string    `json:"body,omitempty" db:"body"`
+	Error         string    `json:"error" db:"error"`
+	Attempts      int       `json:"attempts" db:"attempts"`
+	FirstFailedAt time.Time `json:"firstFailedAt" db:"first_failed_at"`
+	LastFailedAt  time.Time `json:"lastFailedAt" db:"last_failed_at"`
 }
 
 // Pagination represents pagination metadata
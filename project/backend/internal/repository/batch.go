@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"forum-api-wrapper/internal/models"
+	"sort"
+	"time"
+)
+
+// The batch upserts below write all rows of a batch in one transaction, so a
+// batch is stored completely or not at all. Rows are keyed by their upstream IDs
+// and written in ID order: concurrent batches on Postgres then lock shared rows
+// in the same order and cannot deadlock. Each row is one execution of a prepared
+// statement, which keeps batches clear of the bind parameter limits of both
+// Postgres and SQLite.
+//
+// Only the counts listed upstream are written; the counters they sit next to
+// are kept to the stored rows by ReconcileCounters.
+
+const upsertForumQuery = `
+	INSERT INTO forums (id, name, description, upstream_topic_count, upstream_post_count, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	ON CONFLICT (id) DO UPDATE SET
+		name = excluded.name,
+		description = excluded.description,
+		upstream_topic_count = excluded.upstream_topic_count,
+		upstream_post_count = excluded.upstream_post_count,
+		updated_at = CURRENT_TIMESTAMP
+`
+
+const upsertTopicQuery = `
+	INSERT INTO topics (
+		id, title, forum_id, author_id, upstream_reply_count, view_count,
+		last_post_id, last_post_at, created_at, updated_at, search_text
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9, CURRENT_TIMESTAMP), CURRENT_TIMESTAMP, $10)
+	ON CONFLICT (id) DO UPDATE SET
+		title = excluded.title,
+		search_text = excluded.search_text,
+		forum_id = excluded.forum_id,
+		author_id = excluded.author_id,
+		upstream_reply_count = excluded.upstream_reply_count,
+		view_count = excluded.view_count,
+		last_post_id = COALESCE(excluded.last_post_id, topics.last_post_id),
+		last_post_at = COALESCE(excluded.last_post_at, topics.last_post_at),
+		updated_at = CURRENT_TIMESTAMP
+`
+
+const upsertUserQuery = `
+	INSERT INTO users (id, username, search_text)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (id) DO UPDATE SET
+		username = excluded.username,
+		search_text = excluded.search_text
+`
+
+const upsertPostQuery = `
+	INSERT INTO posts (id, topic_id, author_id, content, content_hash, content_markdown, content_text,
+		is_first_post, created_at, updated_at, search_text)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CURRENT_TIMESTAMP, $10)
+	ON CONFLICT (id) DO UPDATE SET
+		topic_id = excluded.topic_id,
+		author_id = excluded.author_id,
+		content = excluded.content,
+		content_hash = excluded.content_hash,
+		content_markdown = excluded.content_markdown,
+		content_text = excluded.content_text,
+		search_text = excluded.search_text,
+		is_first_post = excluded.is_first_post,
+		created_at = excluded.created_at,
+		updated_at = CASE WHEN $11 THEN CURRENT_TIMESTAMP ELSE posts.updated_at END,
+		deleted_at = NULL
+`
+
+// UpsertForums inserts or updates forums in one transaction
+func (r *DBRepository) UpsertForums(ctx context.Context, forums []models.Forum) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, upsertForumQuery)
+		if err != nil {
+			return fmt.Errorf("failed to prepare forum upsert: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, i := range byID(len(forums), func(i int) int { return forums[i].ID }) {
+			f := &forums[i]
+			_, err := stmt.ExecContext(ctx, f.ID, f.Name, f.Description, f.UpstreamTopicCount, f.UpstreamPostCount)
+			if err != nil {
+				return fmt.Errorf("failed to upsert forum %d: %w", f.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// UpsertTopics inserts or updates topics in one transaction. A topic without a
+// last post keeps the stored one, and one without a creation time gets the
+// current time when inserted.
+func (r *DBRepository) UpsertTopics(ctx context.Context, topics []models.Topic) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, upsertTopicQuery)
+		if err != nil {
+			return fmt.Errorf("failed to prepare topic upsert: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, i := range byID(len(topics), func(i int) int { return topics[i].ID }) {
+			t := &topics[i]
+			var createdAt interface{}
+			if !t.CreatedAt.IsZero() {
+				createdAt = t.CreatedAt
+			}
+			_, err := stmt.ExecContext(ctx,
+				t.ID, t.Title, t.ForumID, t.AuthorID, t.UpstreamReplyCount, t.ViewCount,
+				t.LastPostID, t.LastPostAt, createdAt, searchText(t.Title),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to upsert topic %d: %w", t.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// UpsertUsers inserts users or updates their names in one transaction; profile
+// fields are left to UpdateUserProfile
+func (r *DBRepository) UpsertUsers(ctx context.Context, users []models.User) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, upsertUserQuery)
+		if err != nil {
+			return fmt.Errorf("failed to prepare user upsert: %w", err)
+		}
+		defer stmt.Close()
+
+		for _, i := range byID(len(users), func(i int) int { return users[i].ID }) {
+			u := &users[i]
+			if _, err := stmt.ExecContext(ctx, u.ID, u.Username, searchText(u.Username)); err != nil {
+				return fmt.Errorf("failed to upsert user %d: %w", u.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// UpsertPosts inserts or updates posts in one transaction. When the text of an
+// existing post changes, the previous version is kept in post_revisions; see
+// contentHash. A post that was marked deleted is restored.
+func (r *DBRepository) UpsertPosts(ctx context.Context, posts []models.Post) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		load, err := tx.PrepareContext(ctx, "SELECT content, content_text, updated_at FROM posts WHERE id = $1")
+		if err != nil {
+			return fmt.Errorf("failed to prepare post load: %w", err)
+		}
+		defer load.Close()
+		revise, err := tx.PrepareContext(ctx, `
+			INSERT INTO post_revisions (post_id, content, content_hash, created_at, replaced_at)
+			VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare post revision: %w", err)
+		}
+		defer revise.Close()
+		upsert, err := tx.PrepareContext(ctx, upsertPostQuery)
+		if err != nil {
+			return fmt.Errorf("failed to prepare post upsert: %w", err)
+		}
+		defer upsert.Close()
+
+		for _, i := range byID(len(posts), func(i int) int { return posts[i].ID }) {
+			p := &posts[i]
+			hash := contentHash(p.ContentText, p.Content)
+
+			// The stored hash is recomputed rather than read, so rows hashed
+			// another way do not all look edited
+			var oldContent string
+			var oldText sql.NullString
+			var oldUpdatedAt time.Time
+			edited := false
+			err := load.QueryRowContext(ctx, p.ID).Scan(&oldContent, &oldText, &oldUpdatedAt)
+			if err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("failed to load post %d: %w", p.ID, err)
+			}
+			if err == nil {
+				oldHash := contentHash(oldText.String, oldContent)
+				if edited = oldHash != hash; edited {
+					if _, err := revise.ExecContext(ctx, p.ID, oldContent, oldHash, oldUpdatedAt); err != nil {
+						return fmt.Errorf("failed to store revision of post %d: %w", p.ID, err)
+					}
+				}
+			}
+
+			_, err = upsert.ExecContext(ctx,
+				p.ID, p.TopicID, p.AuthorID, p.Content, hash,
+				nullString(p.ContentMarkdown), nullString(p.ContentText), p.IsFirstPost, p.CreatedAt,
+				searchText(postText(p.ContentText, p.Content)), edited,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to upsert post %d: %w", p.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// inTx runs fn in a transaction that is committed if fn succeeds and rolled back otherwise
+func (r *DBRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// byID returns the indexes 0..n-1 ordered by the ID id reports for each
+func byID(n int, id func(i int) int) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return id(order[a]) < id(order[b]) })
+	return order
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"forum-api-wrapper/internal/models"
+)
+
+// counter is a denormalized count column and the expression that recomputes it
+// from the rows it counts, in terms of the counted row aliased x
+type counter struct {
+	entity string
+	table  string
+	column string
+	actual string
+}
+
+// counters are the denormalized counts kept by ReconcileCounters, which is the
+// only writer of these columns; the counts listed upstream are stored apart in
+// the upstream_* columns. Posts marked deleted no longer exist upstream and are
+// not counted.
+var counters = []counter{
+	{models.CounterEntityForum, "forums", "topic_count",
+		`(SELECT COUNT(*) FROM topics t WHERE t.forum_id = x.id)`},
+	{models.CounterEntityForum, "forums", "post_count",
+		`(SELECT COUNT(*) FROM posts p JOIN topics t ON p.topic_id = t.id
+			WHERE t.forum_id = x.id AND p.deleted_at IS NULL)`},
+	{models.CounterEntityTopic, "topics", "reply_count",
+		`(SELECT CASE WHEN COUNT(*) > 0 THEN COUNT(*) - 1 ELSE 0 END FROM posts p
+			WHERE p.topic_id = x.id AND p.deleted_at IS NULL)`},
+	{models.CounterEntityUser, "users", "post_count",
+		`(SELECT COUNT(*) FROM posts p WHERE p.author_id = x.id AND p.deleted_at IS NULL)`},
+	{models.CounterEntityUser, "users", "topic_count",
+		`(SELECT COUNT(*) FROM topics t WHERE t.author_id = x.id)`},
+}
+
+// ReconcileCounters recomputes the forum, topic and user counters from the
+// stored rows in one transaction. Every counter that drifted is corrected and
+// recorded under jobID; the number corrected is returned.
+func (r *DBRepository) ReconcileCounters(ctx context.Context, jobID int) (int, error) {
+	corrected := 0
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		record, err := tx.PrepareContext(ctx, `
+			INSERT INTO counter_drift (job_id, entity, entity_id, counter, stored, actual)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare counter drift insert: %w", err)
+		}
+		defer record.Close()
+
+		for _, c := range counters {
+			drift, err := findCounterDrift(ctx, tx, c)
+			if err != nil {
+				return err
+			}
+
+			update := fmt.Sprintf("UPDATE %s SET %s = $1 WHERE id = $2", c.table, c.column)
+			for _, d := range drift {
+				if _, err := tx.ExecContext(ctx, update, d.Actual, d.EntityID); err != nil {
+					return fmt.Errorf("failed to correct %s %d %s: %w", c.entity, d.EntityID, c.column, err)
+				}
+				_, err := record.ExecContext(ctx, jobID, d.Entity, d.EntityID, d.Counter, d.Stored, d.Actual)
+				if err != nil {
+					return fmt.Errorf("failed to record counter drift: %w", err)
+				}
+			}
+			corrected += len(drift)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to reconcile counters: %w", err)
+	}
+	return corrected, nil
+}
+
+// findCounterDrift lists the rows whose stored counter c differs from the
+// recomputed one. The rows are read in full so they can be updated in the same
+// transaction afterwards.
+func findCounterDrift(ctx context.Context, tx *sql.Tx, c counter) ([]models.CounterDrift, error) {
+	query := fmt.Sprintf(`
+		SELECT id, stored, actual
+		FROM (SELECT x.id, COALESCE(x.%s, 0) AS stored, %s AS actual FROM %s x) c
+		WHERE stored <> actual
+		ORDER BY id
+	`, c.column, c.actual, c.table)
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recompute %s %s: %w", c.entity, c.column, err)
+	}
+	defer rows.Close()
+
+	var drift []models.CounterDrift
+	for rows.Next() {
+		d := models.CounterDrift{Entity: c.entity, Counter: c.column}
+		if err := rows.Scan(&d.EntityID, &d.Stored, &d.Actual); err != nil {
+			return nil, fmt.Errorf("failed to scan %s %s: %w", c.entity, c.column, err)
+		}
+		drift = append(drift, d)
+	}
+	return drift, rows.Err()
+}
+
+// GetCounterDrift retrieves the counters a sync job corrected, in the order
+// they were corrected
+func (r *DBRepository) GetCounterDrift(ctx context.Context, jobID int, page, limit int) ([]models.CounterDrift, int, error) {
+	offset := (page - 1) * limit
+
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM counter_drift WHERE job_id = $1", jobID).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count counter drift: %w", err)
+	}
+
+	query := `
+		SELECT job_id, entity, entity_id, counter, stored, actual
+		FROM counter_drift
+		WHERE job_id = $1
+		ORDER BY id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, jobID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query counter drift: %w", err)
+	}
+	defer rows.Close()
+
+	var drift []models.CounterDrift
+	for rows.Next() {
+		var d models.CounterDrift
+		if err := rows.Scan(&d.JobID, &d.Entity, &d.EntityID, &d.Counter, &d.Stored, &d.Actual); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan counter drift: %w", err)
+		}
+		drift = append(drift, d)
+	}
+
+	return drift, total, rows.Err()
+}
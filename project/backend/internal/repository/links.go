@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"forum-api-wrapper/internal/models"
+)
+
+// ReplacePostLinks replaces the links stored for a post with links, kept in the
+// order they appear in the post
+func (r *DBRepository) ReplacePostLinks(ctx context.Context, postID int, links []models.PostLink) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM post_links WHERE post_id = $1", postID); err != nil {
+		return fmt.Errorf("failed to clear post links: %w", err)
+	}
+	for i, link := range links {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO post_links (post_id, position, url, target_topic_id, target_post_id)
+			VALUES ($1, $2, $3, $4, $5)
+		`, postID, i, link.URL, link.TargetTopicID, link.TargetPostID)
+		if err != nil {
+			return fmt.Errorf("failed to store post link: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit post links: %w", err)
+	}
+	return nil
+}
+
+// topicLinkColumns are the post_links columns (l) joined with the linking post
+// (p), its topic (s) and the linked topic if mirrored (t), read by scanTopicLink
+const topicLinkColumns = `p.id, p.topic_id, s.title, l.url, l.target_topic_id, l.target_post_id, t.title, p.created_at`
+
+// GetTopicOutlinks retrieves links from the posts of a topic to other topics,
+// in the order the posts were made
+func (r *DBRepository) GetTopicOutlinks(ctx context.Context, topicID int, page, limit int) ([]models.TopicLink, int, error) {
+	where := "p.topic_id = $1 AND l.target_topic_id IS NOT NULL AND l.target_topic_id <> p.topic_id"
+	return r.getTopicLinks(ctx, where, topicID, page, limit)
+}
+
+// GetTopicBacklinks retrieves links to a topic from the posts of other topics,
+// in the order the posts were made
+func (r *DBRepository) GetTopicBacklinks(ctx context.Context, topicID int, page, limit int) ([]models.TopicLink, int, error) {
+	where := "l.target_topic_id = $1 AND p.topic_id <> l.target_topic_id"
+	return r.getTopicLinks(ctx, where, topicID, page, limit)
+}
+
+// getTopicLinks retrieves a page of the topic links matching where, which
+// refers to the topic ID as $1
+func (r *DBRepository) getTopicLinks(ctx context.Context, where string, topicID int, page, limit int) ([]models.TopicLink, int, error) {
+	offset := (page - 1) * limit
+
+	var total int
+	countQuery := `
+		SELECT COUNT(*)
+		FROM post_links l
+		JOIN posts p ON l.post_id = p.id
+		WHERE ` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, topicID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count topic links: %w", err)
+	}
+
+	query := `
+		SELECT ` + topicLinkColumns + `
+		FROM post_links l
+		JOIN posts p ON l.post_id = p.id
+		JOIN topics s ON p.topic_id = s.id
+		LEFT JOIN topics t ON l.target_topic_id = t.id
+		WHERE ` + where + `
+		ORDER BY p.created_at ASC, p.id ASC, l.position ASC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, topicID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query topic links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []models.TopicLink
+	for rows.Next() {
+		link, err := scanTopicLink(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan topic link: %w", err)
+		}
+		links = append(links, *link)
+	}
+
+	return links, total, rows.Err()
+}
+
+// scanTopicLink scans a row selected with topicLinkColumns
+func scanTopicLink(row interface{ Scan(dest ...any) error }) (*models.TopicLink, error) {
+	var link models.TopicLink
+	var targetPostID sql.NullInt64
+	var targetTitle sql.NullString
+	err := row.Scan(
+		&link.SourcePostID, &link.SourceTopicID, &link.SourceTopicTitle, &link.URL,
+		&link.TargetTopicID, &targetPostID, &targetTitle, &link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if targetPostID.Valid {
+		id := int(targetPostID.Int64)
+		link.TargetPostID = &id
+	}
+	if targetTitle.Valid {
+		link.TargetTopicTitle = &targetTitle.String
+		link.Mirrored = true
+	}
+	return &link, nil
+}
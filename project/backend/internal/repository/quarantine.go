@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"forum-api-wrapper/internal/models"
+)
+
+const quarantineColumns = `id, url, path, body_ref, error, attempts, first_failed_at, last_failed_at`
+
+// QuarantinePage records a page that failed to parse. A page already in
+// quarantine has its error, body and failure time updated and its attempt
+// count increased. The stored row is read back into page, body excepted.
+func (r *DBRepository) QuarantinePage(ctx context.Context, page *models.QuarantinedPage) error {
+	query := `
+		INSERT INTO parse_quarantine (url, path, body_ref, body, error, attempts, first_failed_at, last_failed_at)
+		VALUES ($1, $2, $3, $4, $5, 1, $6, $6)
+		ON CONFLICT (path) DO UPDATE SET
+			url = excluded.url,
+			body_ref = excluded.body_ref,
+			body = excluded.body,
+			error = excluded.error,
+			attempts = parse_quarantine.attempts + 1,
+			last_failed_at = excluded.last_failed_at
+		RETURNING ` + quarantineColumns
+
+	stored, err := scanQuarantinedPage(r.db.QueryRowContext(ctx, query,
+		page.URL, page.Path, nullString(page.BodyRef), nullString(page.Body), page.Error, page.LastFailedAt,
+	))
+	if err != nil {
+		return fmt.Errorf("failed to quarantine page: %w", err)
+	}
+	*page = *stored
+	return nil
+}
+
+// GetQuarantinedPages retrieves quarantined pages, most recently failed first
+func (r *DBRepository) GetQuarantinedPages(ctx context.Context, page, limit int) ([]models.QuarantinedPage, int, error) {
+	offset := (page - 1) * limit
+
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM parse_quarantine").Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count quarantined pages: %w", err)
+	}
+
+	query := `SELECT ` + quarantineColumns + `
+		FROM parse_quarantine
+		ORDER BY last_failed_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query quarantined pages: %w", err)
+	}
+	defer rows.Close()
+
+	var pages []models.QuarantinedPage
+	for rows.Next() {
+		p, err := scanQuarantinedPage(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan quarantined page: %w", err)
+		}
+		pages = append(pages, *p)
+	}
+
+	return pages, total, nil
+}
+
+// GetQuarantinedPageByID retrieves a quarantined page by ID, with its body
+func (r *DBRepository) GetQuarantinedPageByID(ctx context.Context, id int) (*models.QuarantinedPage, error) {
+	query := `SELECT ` + quarantineColumns + `, body FROM parse_quarantine WHERE id = $1`
+
+	var body sql.NullString
+	page, err := scanQuarantinedPage(r.db.QueryRowContext(ctx, query, id), &body)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined page: %w", err)
+	}
+	page.Body = body.String
+	return page, nil
+}
+
+// DeleteQuarantinedPage removes a page from quarantine and reports whether it was there
+func (r *DBRepository) DeleteQuarantinedPage(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM parse_quarantine WHERE id = $1", id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete quarantined page: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete quarantined page: %w", err)
+	}
+	return n > 0, nil
+}
+
+// scanQuarantinedPage scans a row selected with quarantineColumns, followed by
+// the columns scanned into extra
+func scanQuarantinedPage(row interface{ Scan(dest ...any) error }, extra ...any) (*models.QuarantinedPage, error) {
+	var page models.QuarantinedPage
+	var bodyRef sql.NullString
+	dest := []any{
+		&page.ID, &page.URL, &page.Path, &bodyRef, &page.Error,
+		&page.Attempts, &page.FirstFailedAt, &page.LastFailedAt,
+	}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	page.BodyRef = bodyRef.String
+	return &page, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"forum-api-wrapper/internal/models"
+)
+
+// ReplacePostQuotes replaces the posts a post quotes with quotedIDs, kept in
+// the order the quotes appear in the post
+func (r *DBRepository) ReplacePostQuotes(ctx context.Context, postID int, quotedIDs []int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM post_quotes WHERE post_id = $1", postID); err != nil {
+		return fmt.Errorf("failed to clear post quotes: %w", err)
+	}
+	for i, quotedID := range quotedIDs {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO post_quotes (post_id, quoted_post_id, position) VALUES ($1, $2, $3)",
+			postID, quotedID, i,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store post quote: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit post quotes: %w", err)
+	}
+	return nil
+}
+
+// FindQuotedPost returns the ID of the latest post in a topic by the named
+// author that precedes beforeID, or 0 if there is none
+func (r *DBRepository) FindQuotedPost(ctx context.Context, topicID int, author string, beforeID int) (int, error) {
+	query := `
+		SELECT p.id
+		FROM posts p
+		JOIN users u ON p.author_id = u.id
+		WHERE p.topic_id = $1 AND u.username = $2 AND p.id < $3
+		ORDER BY p.id DESC
+		LIMIT 1
+	`
+
+	var id int
+	err := r.db.QueryRowContext(ctx, query, topicID, author, beforeID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find quoted post: %w", err)
+	}
+	return id, nil
+}
+
+// GetPostReplies retrieves the posts that quote a post, oldest first
+func (r *DBRepository) GetPostReplies(ctx context.Context, postID int) ([]models.Post, error) {
+	query := `
+		SELECT ` + postColumns + `
+		FROM post_quotes q
+		JOIN posts p ON q.post_id = p.id
+		JOIN topics t ON p.topic_id = t.id
+		JOIN users u ON p.author_id = u.id
+		WHERE q.quoted_post_id = $1
+		ORDER BY p.created_at ASC, p.id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post replies: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		p, err := scanPost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, *p)
+	}
+
+	return posts, rows.Err()
+}
+
+// GetTopicQuotes retrieves the quotes made by the posts of a topic, ordered by
+// post and then by position within the post
+func (r *DBRepository) GetTopicQuotes(ctx context.Context, topicID int) ([]models.PostQuote, error) {
+	query := `
+		SELECT q.post_id, q.quoted_post_id
+		FROM post_quotes q
+		JOIN posts p ON q.post_id = p.id
+		WHERE p.topic_id = $1
+		ORDER BY q.post_id, q.position
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, topicID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query topic quotes: %w", err)
+	}
+	defer rows.Close()
+
+	var quotes []models.PostQuote
+	for rows.Next() {
+		var q models.PostQuote
+		if err := rows.Scan(&q.PostID, &q.QuotedPostID); err != nil {
+			return nil, fmt.Errorf("failed to scan post quote: %w", err)
+		}
+		quotes = append(quotes, q)
+	}
+
+	return quotes, rows.Err()
+}
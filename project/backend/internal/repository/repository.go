@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"forum-api-wrapper/internal/models"
+	"strings"
 	"time"
 )
 
@@ -13,22 +16,63 @@ type Repository interface {
 	// Forums
 	GetForums(ctx context.Context, page, limit int) ([]models.Forum, int, error)
 	GetForumByID(ctx context.Context, id int) (*models.Forum, error)
+	UpsertForum(ctx context.Context, forum *models.Forum) error
+	UpsertForums(ctx context.Context, forums []models.Forum) error
 
 	// Topics
 	GetTopics(ctx context.Context, filter TopicFilter, page, limit int) ([]models.Topic, int, error)
 	GetTopicByID(ctx context.Context, id int) (*models.Topic, error)
 	GetTopicPosts(ctx context.Context, topicID int, page, limit int) ([]models.Post, int, error)
+	UpsertTopic(ctx context.Context, topic *models.Topic) error
+	UpsertTopics(ctx context.Context, topics []models.Topic) error
+	GetTopicSyncStates(ctx context.Context, topicIDs []int) (map[int]TopicSyncState, error)
+	GetAllTopicPosts(ctx context.Context, topicID int) ([]models.Post, error)
 
 	// Posts
 	GetPosts(ctx context.Context, filter PostFilter, page, limit int) ([]models.Post, int, error)
 	GetPostByID(ctx context.Context, id int) (*models.Post, error)
+	UpsertPost(ctx context.Context, post *models.Post) error
+	UpsertPosts(ctx context.Context, posts []models.Post) error
+	MarkPostsDeleted(ctx context.Context, topicID int, keepIDs []int) (int, error)
+	GetPostRevisions(ctx context.Context, postID int) ([]models.PostRevision, error)
+
+	// Quotes
+	ReplacePostQuotes(ctx context.Context, postID int, quotedIDs []int) error
+	FindQuotedPost(ctx context.Context, topicID int, author string, beforeID int) (int, error)
+	GetPostReplies(ctx context.Context, postID int) ([]models.Post, error)
+	GetTopicQuotes(ctx context.Context, topicID int) ([]models.PostQuote, error)
+
+	// Links
+	ReplacePostLinks(ctx context.Context, postID int, links []models.PostLink) error
+	GetTopicOutlinks(ctx context.Context, topicID int, page, limit int) ([]models.TopicLink, int, error)
+	GetTopicBacklinks(ctx context.Context, topicID int, page, limit int) ([]models.TopicLink, int, error)
 
 	// Users
 	GetUsers(ctx context.Context, page, limit int) ([]models.User, int, error)
 	GetUserByID(ctx context.Context, id int) (*models.User, error)
+	UpsertUser(ctx context.Context, user *models.User) error
+	UpsertUsers(ctx context.Context, users []models.User) error
+	UpdateUserProfile(ctx context.Context, user *models.User) error
+	GetStaleProfiles(ctx context.Context, syncedBefore time.Time, limit int) ([]int, error)
 
 	// Search
 	Search(ctx context.Context, query string, searchType string, forumID *int, page, limit int) (SearchResults, int, error)
+
+	// Sync jobs
+	CreateSyncJob(ctx context.Context, job *models.SyncJob) error
+	UpdateSyncJob(ctx context.Context, job *models.SyncJob) error
+	GetSyncJobs(ctx context.Context, page, limit int) ([]models.SyncJob, int, error)
+	GetSyncJobByID(ctx context.Context, id int) (*models.SyncJob, error)
+
+	// Counters
+	ReconcileCounters(ctx context.Context, jobID int) (int, error)
+	GetCounterDrift(ctx context.Context, jobID int, page, limit int) ([]models.CounterDrift, int, error)
+
+	// Parse quarantine
+	QuarantinePage(ctx context.Context, page *models.QuarantinedPage) error
+	GetQuarantinedPages(ctx context.Context, page, limit int) ([]models.QuarantinedPage, int, error)
+	GetQuarantinedPageByID(ctx context.Context, id int) (*models.QuarantinedPage, error)
+	DeleteQuarantinedPage(ctx context.Context, id int) (bool, error)
 }
 
 // TopicFilter filters for topic queries
@@ -43,6 +87,12 @@ type PostFilter struct {
 	UserID  *int
 }
 
+// TopicSyncState is the locally stored watermark of a topic used by incremental sync
+type TopicSyncState struct {
+	LastPostAt *time.Time
+	PostCount  int
+}
+
 // SearchResults contains search results
 type SearchResults struct {
 	Topics []models.Topic
@@ -73,7 +123,8 @@ func (r *DBRepository) GetForums(ctx context.Context, page, limit int) ([]models
 
 	// Get forums
 	query := `
-		SELECT id, name, description, topic_count, post_count, created_at, updated_at
+		SELECT id, name, description, topic_count, post_count,
+			upstream_topic_count, upstream_post_count, created_at, updated_at
 		FROM forums
 		ORDER BY name
 		LIMIT $1 OFFSET $2
@@ -88,7 +139,10 @@ func (r *DBRepository) GetForums(ctx context.Context, page, limit int) ([]models
 	var forums []models.Forum
 	for rows.Next() {
 		var f models.Forum
-		err := rows.Scan(&f.ID, &f.Name, &f.Description, &f.TopicCount, &f.PostCount, &f.CreatedAt, &f.UpdatedAt)
+		err := rows.Scan(
+			&f.ID, &f.Name, &f.Description, &f.TopicCount, &f.PostCount,
+			&f.UpstreamTopicCount, &f.UpstreamPostCount, &f.CreatedAt, &f.UpdatedAt,
+		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan forum: %w", err)
 		}
@@ -101,14 +155,16 @@ func (r *DBRepository) GetForums(ctx context.Context, page, limit int) ([]models
 // GetForumByID retrieves a forum by ID
 func (r *DBRepository) GetForumByID(ctx context.Context, id int) (*models.Forum, error) {
 	query := `
-		SELECT id, name, description, topic_count, post_count, created_at, updated_at
+		SELECT id, name, description, topic_count, post_count,
+			upstream_topic_count, upstream_post_count, created_at, updated_at
 		FROM forums
 		WHERE id = $1
 	`
 
 	var f models.Forum
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&f.ID, &f.Name, &f.Description, &f.TopicCount, &f.PostCount, &f.CreatedAt, &f.UpdatedAt,
+		&f.ID, &f.Name, &f.Description, &f.TopicCount, &f.PostCount,
+		&f.UpstreamTopicCount, &f.UpstreamPostCount, &f.CreatedAt, &f.UpdatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -120,6 +176,11 @@ func (r *DBRepository) GetForumByID(ctx context.Context, id int) (*models.Forum,
 	return &f, nil
 }
 
+// UpsertForum inserts a forum or updates it if a forum with the same ID exists
+func (r *DBRepository) UpsertForum(ctx context.Context, forum *models.Forum) error {
+	return r.UpsertForums(ctx, []models.Forum{*forum})
+}
+
 // GetTopics retrieves topics with filtering and pagination
 func (r *DBRepository) GetTopics(ctx context.Context, filter TopicFilter, page, limit int) ([]models.Topic, int, error) {
 	offset := (page - 1) * limit
@@ -130,13 +191,13 @@ func (r *DBRepository) GetTopics(ctx context.Context, filter TopicFilter, page,
 	argPos := 1
 
 	if filter.ForumID != nil {
-		whereClause += fmt.Sprintf(" AND forum_id = $%d", argPos)
+		whereClause += fmt.Sprintf(" AND t.forum_id = $%d", argPos)
 		args = append(args, *filter.ForumID)
 		argPos++
 	}
 
 	// Get total count
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM topics WHERE %s", whereClause)
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM topics t WHERE %s", whereClause)
 	var total int
 	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
@@ -144,14 +205,14 @@ func (r *DBRepository) GetTopics(ctx context.Context, filter TopicFilter, page,
 	}
 
 	// Determine sort order
-	orderBy := "created_at DESC"
+	orderBy := "t.created_at DESC"
 	switch filter.Sort {
 	case "oldest":
-		orderBy = "created_at ASC"
+		orderBy = "t.created_at ASC"
 	case "most_replies":
-		orderBy = "reply_count DESC"
+		orderBy = "t.reply_count DESC"
 	case "most_views":
-		orderBy = "view_count DESC"
+		orderBy = "t.view_count DESC"
 	}
 
 	// Get topics
@@ -159,7 +220,7 @@ func (r *DBRepository) GetTopics(ctx context.Context, filter TopicFilter, page,
 		SELECT 
 			t.id, t.title, t.forum_id, f.name as forum_name,
 			t.author_id, u.username as author_name,
-			t.reply_count, t.view_count,
+			t.reply_count, t.upstream_reply_count, t.view_count,
 			t.last_post_id, t.last_post_at,
 			t.created_at, t.updated_at
 		FROM topics t
@@ -186,7 +247,7 @@ func (r *DBRepository) GetTopics(ctx context.Context, filter TopicFilter, page,
 		err := rows.Scan(
 			&t.ID, &t.Title, &t.ForumID, &t.ForumName,
 			&t.AuthorID, &t.AuthorName,
-			&t.ReplyCount, &t.ViewCount,
+			&t.ReplyCount, &t.UpstreamReplyCount, &t.ViewCount,
 			&lastPostID, &lastPostAt,
 			&t.CreatedAt, &t.UpdatedAt,
 		)
@@ -214,7 +275,7 @@ func (r *DBRepository) GetTopicByID(ctx context.Context, id int) (*models.Topic,
 		SELECT 
 			t.id, t.title, t.forum_id, f.name as forum_name,
 			t.author_id, u.username as author_name,
-			t.reply_count, t.view_count,
+			t.reply_count, t.upstream_reply_count, t.view_count,
 			t.last_post_id, t.last_post_at,
 			t.created_at, t.updated_at
 		FROM topics t
@@ -230,7 +291,7 @@ func (r *DBRepository) GetTopicByID(ctx context.Context, id int) (*models.Topic,
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&t.ID, &t.Title, &t.ForumID, &t.ForumName,
 		&t.AuthorID, &t.AuthorName,
-		&t.ReplyCount, &t.ViewCount,
+		&t.ReplyCount, &t.UpstreamReplyCount, &t.ViewCount,
 		&lastPostID, &lastPostAt,
 		&t.CreatedAt, &t.UpdatedAt,
 	)
@@ -252,6 +313,57 @@ func (r *DBRepository) GetTopicByID(ctx context.Context, id int) (*models.Topic,
 	return &t, nil
 }
 
+// UpsertTopic inserts a topic or updates it if a topic with the same ID exists.
+// The creation time is only set on insert; a missing last post ID keeps the stored one.
+func (r *DBRepository) UpsertTopic(ctx context.Context, topic *models.Topic) error {
+	return r.UpsertTopics(ctx, []models.Topic{*topic})
+}
+
+// GetTopicSyncStates returns the stored last post time and post count of the given
+// topics; topics that are not stored yet are absent from the result. Posts marked
+// deleted are not counted, as they no longer take up a place on the topic's pages.
+func (r *DBRepository) GetTopicSyncStates(ctx context.Context, topicIDs []int) (map[int]TopicSyncState, error) {
+	states := make(map[int]TopicSyncState, len(topicIDs))
+	if len(topicIDs) == 0 {
+		return states, nil
+	}
+
+	placeholders := make([]string, len(topicIDs))
+	args := make([]interface{}, len(topicIDs))
+	for i, id := range topicIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+
+	query := fmt.Sprintf(`
+		SELECT t.id, t.last_post_at,
+			(SELECT COUNT(*) FROM posts p WHERE p.topic_id = t.id AND p.deleted_at IS NULL)
+		FROM topics t
+		WHERE t.id IN (%s)
+	`, strings.Join(placeholders, ", "))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query topic sync states: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var state TopicSyncState
+		var lastPostAt sql.NullTime
+		if err := rows.Scan(&id, &lastPostAt, &state.PostCount); err != nil {
+			return nil, fmt.Errorf("failed to scan topic sync state: %w", err)
+		}
+		if lastPostAt.Valid {
+			state.LastPostAt = &lastPostAt.Time
+		}
+		states[id] = state
+	}
+
+	return states, rows.Err()
+}
+
 // GetTopicPosts retrieves posts for a topic
 func (r *DBRepository) GetTopicPosts(ctx context.Context, topicID int, page, limit int) ([]models.Post, int, error) {
 	offset := (page - 1) * limit
@@ -265,11 +377,7 @@ func (r *DBRepository) GetTopicPosts(ctx context.Context, topicID int, page, lim
 
 	// Get posts
 	query := `
-		SELECT 
-			p.id, p.topic_id, t.title as topic_title,
-			p.author_id, u.username as author_name,
-			p.content, p.is_first_post,
-			p.created_at, p.updated_at
+		SELECT ` + postColumns + `
 		FROM posts p
 		JOIN topics t ON p.topic_id = t.id
 		JOIN users u ON p.author_id = u.id
@@ -286,22 +394,45 @@ func (r *DBRepository) GetTopicPosts(ctx context.Context, topicID int, page, lim
 
 	var posts []models.Post
 	for rows.Next() {
-		var p models.Post
-		err := rows.Scan(
-			&p.ID, &p.TopicID, &p.TopicTitle,
-			&p.AuthorID, &p.AuthorName,
-			&p.Content, &p.IsFirstPost,
-			&p.CreatedAt, &p.UpdatedAt,
-		)
+		p, err := scanPost(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan post: %w", err)
 		}
-		posts = append(posts, p)
+		posts = append(posts, *p)
 	}
 
 	return posts, total, nil
 }
 
+// GetAllTopicPosts retrieves every post of a topic, oldest first
+func (r *DBRepository) GetAllTopicPosts(ctx context.Context, topicID int) ([]models.Post, error) {
+	query := `
+		SELECT ` + postColumns + `
+		FROM posts p
+		JOIN topics t ON p.topic_id = t.id
+		JOIN users u ON p.author_id = u.id
+		WHERE p.topic_id = $1
+		ORDER BY p.created_at ASC, p.id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, topicID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		p, err := scanPost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, *p)
+	}
+
+	return posts, rows.Err()
+}
+
 // GetPosts retrieves posts with filtering and pagination
 func (r *DBRepository) GetPosts(ctx context.Context, filter PostFilter, page, limit int) ([]models.Post, int, error) {
 	offset := (page - 1) * limit
@@ -311,18 +442,18 @@ func (r *DBRepository) GetPosts(ctx context.Context, filter PostFilter, page, li
 	argPos := 1
 
 	if filter.TopicID != nil {
-		whereClause += fmt.Sprintf(" AND topic_id = $%d", argPos)
+		whereClause += fmt.Sprintf(" AND p.topic_id = $%d", argPos)
 		args = append(args, *filter.TopicID)
 		argPos++
 	}
 	if filter.UserID != nil {
-		whereClause += fmt.Sprintf(" AND author_id = $%d", argPos)
+		whereClause += fmt.Sprintf(" AND p.author_id = $%d", argPos)
 		args = append(args, *filter.UserID)
 		argPos++
 	}
 
 	// Get total count
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM posts WHERE %s", whereClause)
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM posts p WHERE %s", whereClause)
 	var total int
 	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
@@ -331,11 +462,7 @@ func (r *DBRepository) GetPosts(ctx context.Context, filter PostFilter, page, li
 
 	// Get posts
 	query := fmt.Sprintf(`
-		SELECT 
-			p.id, p.topic_id, t.title as topic_title,
-			p.author_id, u.username as author_name,
-			p.content, p.is_first_post,
-			p.created_at, p.updated_at
+		SELECT ` + postColumns + `
 		FROM posts p
 		JOIN topics t ON p.topic_id = t.id
 		JOIN users u ON p.author_id = u.id
@@ -353,17 +480,11 @@ func (r *DBRepository) GetPosts(ctx context.Context, filter PostFilter, page, li
 
 	var posts []models.Post
 	for rows.Next() {
-		var p models.Post
-		err := rows.Scan(
-			&p.ID, &p.TopicID, &p.TopicTitle,
-			&p.AuthorID, &p.AuthorName,
-			&p.Content, &p.IsFirstPost,
-			&p.CreatedAt, &p.UpdatedAt,
-		)
+		p, err := scanPost(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan post: %w", err)
 		}
-		posts = append(posts, p)
+		posts = append(posts, *p)
 	}
 
 	return posts, total, nil
@@ -372,24 +493,14 @@ func (r *DBRepository) GetPosts(ctx context.Context, filter PostFilter, page, li
 // GetPostByID retrieves a post by ID
 func (r *DBRepository) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
 	query := `
-		SELECT 
-			p.id, p.topic_id, t.title as topic_title,
-			p.author_id, u.username as author_name,
-			p.content, p.is_first_post,
-			p.created_at, p.updated_at
+		SELECT ` + postColumns + `
 		FROM posts p
 		JOIN topics t ON p.topic_id = t.id
 		JOIN users u ON p.author_id = u.id
 		WHERE p.id = $1
 	`
 
-	var p models.Post
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&p.ID, &p.TopicID, &p.TopicTitle,
-		&p.AuthorID, &p.AuthorName,
-		&p.Content, &p.IsFirstPost,
-		&p.CreatedAt, &p.UpdatedAt,
-	)
+	p, err := scanPost(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -397,9 +508,120 @@ func (r *DBRepository) GetPostByID(ctx context.Context, id int) (*models.Post, e
 		return nil, fmt.Errorf("failed to get post: %w", err)
 	}
 
+	return p, nil
+}
+
+// postColumns are the posts columns, joined with their topic (t) and author (u), read by scanPost
+const postColumns = `p.id, p.topic_id, t.title as topic_title,
+			p.author_id, u.username as author_name,
+			p.content, p.content_markdown, p.content_text, p.is_first_post,
+			p.created_at, p.updated_at, p.deleted_at`
+
+// scanPost scans a row selected with postColumns
+func scanPost(row interface{ Scan(dest ...any) error }) (*models.Post, error) {
+	var p models.Post
+	var markdown, text sql.NullString
+	var deletedAt sql.NullTime
+	err := row.Scan(
+		&p.ID, &p.TopicID, &p.TopicTitle,
+		&p.AuthorID, &p.AuthorName,
+		&p.Content, &markdown, &text, &p.IsFirstPost,
+		&p.CreatedAt, &p.UpdatedAt, &deletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.ContentMarkdown = markdown.String
+	p.ContentText = text.String
+	if deletedAt.Valid {
+		p.DeletedAt = &deletedAt.Time
+	}
 	return &p, nil
 }
 
+// UpsertPost inserts a post or updates it if a post with the same ID exists,
+// see UpsertPosts
+func (r *DBRepository) UpsertPost(ctx context.Context, post *models.Post) error {
+	return r.UpsertPosts(ctx, []models.Post{*post})
+}
+
+// MarkPostsDeleted marks the posts of a topic that are not in keepIDs as deleted
+// and returns how many were newly marked
+func (r *DBRepository) MarkPostsDeleted(ctx context.Context, topicID int, keepIDs []int) (int, error) {
+	args := []interface{}{topicID}
+	query := "UPDATE posts SET deleted_at = CURRENT_TIMESTAMP WHERE topic_id = $1 AND deleted_at IS NULL"
+	if len(keepIDs) > 0 {
+		placeholders := make([]string, len(keepIDs))
+		for i, id := range keepIDs {
+			placeholders[i] = fmt.Sprintf("$%d", i+2)
+			args = append(args, id)
+		}
+		query += fmt.Sprintf(" AND id NOT IN (%s)", strings.Join(placeholders, ", "))
+	}
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark posts deleted: %w", err)
+	}
+	marked, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark posts deleted: %w", err)
+	}
+	return int(marked), nil
+}
+
+// GetPostRevisions retrieves the earlier versions of a post, oldest first
+func (r *DBRepository) GetPostRevisions(ctx context.Context, postID int) ([]models.PostRevision, error) {
+	query := `
+		SELECT id, post_id, content, content_hash, created_at, replaced_at
+		FROM post_revisions
+		WHERE post_id = $1
+		ORDER BY replaced_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, postID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []models.PostRevision
+	for rows.Next() {
+		var rev models.PostRevision
+		err := rows.Scan(&rev.ID, &rev.PostID, &rev.Content, &rev.ContentHash, &rev.CreatedAt, &rev.ReplacedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan post revision: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+// contentHash returns the hex SHA-256 of a post's plain text with whitespace
+// collapsed, which revisions are told apart by: a change in how the forum markup
+// is rendered, applied by a reparse, is not an edit. Posts without plain text
+// are hashed by their content.
+func contentHash(text, content string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(postText(text, content)), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// postText returns a post's plain text, or its content when it has none
+func postText(text, content string) string {
+	if text == "" {
+		return content
+	}
+	return text
+}
+
+// searchText folds s to lower case for Search. The folding is done here rather
+// than with LOWER, which in SQLite only folds ASCII letters, so that search is
+// case-insensitive for Cyrillic text on both databases.
+func searchText(s string) string {
+	return strings.ToLower(s)
+}
+
 // GetUsers retrieves users with pagination
 func (r *DBRepository) GetUsers(ctx context.Context, page, limit int) ([]models.User, int, error) {
 	offset := (page - 1) * limit
@@ -413,7 +635,7 @@ func (r *DBRepository) GetUsers(ctx context.Context, page, limit int) ([]models.
 
 	// Get users
 	query := `
-		SELECT id, username, post_count, topic_count, registered_at, last_active_at
+		SELECT ` + userColumns + `
 		FROM users
 		ORDER BY username
 		LIMIT $1 OFFSET $2
@@ -427,22 +649,11 @@ func (r *DBRepository) GetUsers(ctx context.Context, page, limit int) ([]models.
 
 	var users []models.User
 	for rows.Next() {
-		var u models.User
-		var lastActiveAt sql.NullTime
-
-		err := rows.Scan(
-			&u.ID, &u.Username, &u.PostCount, &u.TopicCount,
-			&u.RegisteredAt, &lastActiveAt,
-		)
+		u, err := scanUser(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan user: %w", err)
 		}
-
-		if lastActiveAt.Valid {
-			u.LastActiveAt = &lastActiveAt.Time
-		}
-
-		users = append(users, u)
+		users = append(users, *u)
 	}
 
 	return users, total, nil
@@ -451,18 +662,12 @@ func (r *DBRepository) GetUsers(ctx context.Context, page, limit int) ([]models.
 // GetUserByID retrieves a user by ID
 func (r *DBRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
 	query := `
-		SELECT id, username, post_count, topic_count, registered_at, last_active_at
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE id = $1
 	`
 
-	var u models.User
-	var lastActiveAt sql.NullTime
-
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&u.ID, &u.Username, &u.PostCount, &u.TopicCount,
-		&u.RegisteredAt, &lastActiveAt,
-	)
+	u, err := scanUser(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -470,20 +675,119 @@ func (r *DBRepository) GetUserByID(ctx context.Context, id int) (*models.User, e
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
+	return u, nil
+}
+
+// userColumns are the users columns read by scanUser
+const userColumns = `id, username, post_count, topic_count, upstream_post_count, registered_at, last_active_at,
+		location, rank, profile_synced_at`
+
+// scanUser scans a row selected with userColumns
+func scanUser(row interface{ Scan(dest ...any) error }) (*models.User, error) {
+	var u models.User
+	var lastActiveAt, profileSyncedAt sql.NullTime
+	var location, rank sql.NullString
+	err := row.Scan(
+		&u.ID, &u.Username, &u.PostCount, &u.TopicCount, &u.UpstreamPostCount,
+		&u.RegisteredAt, &lastActiveAt, &location, &rank, &profileSyncedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
 	if lastActiveAt.Valid {
 		u.LastActiveAt = &lastActiveAt.Time
 	}
-
+	if profileSyncedAt.Valid {
+		u.ProfileSyncedAt = &profileSyncedAt.Time
+	}
+	u.Location = location.String
+	u.Rank = rank.String
 	return &u, nil
 }
 
+// UpsertUser inserts a user or updates it if a user with the same ID exists
+func (r *DBRepository) UpsertUser(ctx context.Context, user *models.User) error {
+	return r.UpsertUsers(ctx, []models.User{*user})
+}
+
+// UpdateUserProfile stores the fields scraped from a user's profile page and
+// records when the profile was synced. The user must already exist. The post
+// total goes to UpstreamPostCount; PostCount is left to ReconcileCounters.
+func (r *DBRepository) UpdateUserProfile(ctx context.Context, user *models.User) error {
+	query := `
+		UPDATE users SET
+			username = $1,
+			upstream_post_count = $2,
+			registered_at = $3,
+			last_active_at = $4,
+			location = $5,
+			rank = $6,
+			profile_synced_at = CURRENT_TIMESTAMP,
+			search_text = $7
+		WHERE id = $8
+	`
+
+	res, err := r.db.ExecContext(ctx, query,
+		user.Username, user.UpstreamPostCount, user.RegisteredAt, user.LastActiveAt,
+		nullString(user.Location), nullString(user.Rank), searchText(user.Username), user.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user profile: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("failed to update user profile: user %d not found", user.ID)
+	}
+
+	return nil
+}
+
+// GetStaleProfiles returns up to limit IDs of users whose profile was never
+// synced or last synced before syncedBefore, least recently synced first.
+// The guest placeholder (ID 0) has no profile and is never returned.
+func (r *DBRepository) GetStaleProfiles(ctx context.Context, syncedBefore time.Time, limit int) ([]int, error) {
+	query := `
+		SELECT id FROM users
+		WHERE id > 0 AND (profile_synced_at IS NULL OR profile_synced_at < $1)
+		ORDER BY profile_synced_at IS NOT NULL, profile_synced_at, id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, syncedBefore.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stale profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan user id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query stale profiles: %w", err)
+	}
+
+	return ids, nil
+}
+
+// nullString stores empty strings as NULL
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 // Search performs a full-text search across topics, posts, and users
 func (r *DBRepository) Search(ctx context.Context, query string, searchType string, forumID *int, page, limit int) (SearchResults, int, error) {
 	offset := (page - 1) * limit
 	results := SearchResults{}
 
-	// Build search conditions
-	searchPattern := "%" + query + "%"
+	// Build search conditions; rows stored without search_text fall back to LOWER
+	searchPattern := "%" + searchText(query) + "%"
 	args := []interface{}{searchPattern}
 	argPos := 2
 
@@ -500,8 +804,8 @@ func (r *DBRepository) Search(ctx context.Context, query string, searchType stri
 	if searchType == "all" || searchType == "topics" {
 		querySQL := fmt.Sprintf(`
 			SELECT COUNT(*) FROM topics t
-			WHERE (t.title ILIKE $1 OR EXISTS (
-				SELECT 1 FROM posts p WHERE p.topic_id = t.id AND p.content ILIKE $1
+			WHERE (COALESCE(t.search_text, LOWER(t.title)) LIKE $1 OR EXISTS (
+				SELECT 1 FROM posts p WHERE p.topic_id = t.id AND COALESCE(p.search_text, LOWER(COALESCE(p.content_text, p.content))) LIKE $1
 			)) %s
 		`, forumClause)
 		err := r.db.QueryRowContext(ctx, querySQL, args...).Scan(&totalResults)
@@ -510,14 +814,14 @@ func (r *DBRepository) Search(ctx context.Context, query string, searchType stri
 				SELECT DISTINCT
 					t.id, t.title, t.forum_id, f.name as forum_name,
 					t.author_id, u.username as author_name,
-					t.reply_count, t.view_count,
+					t.reply_count, t.upstream_reply_count, t.view_count,
 					t.last_post_id, t.last_post_at,
 					t.created_at, t.updated_at
 				FROM topics t
 				JOIN forums f ON t.forum_id = f.id
 				JOIN users u ON t.author_id = u.id
-				WHERE (t.title ILIKE $1 OR EXISTS (
-					SELECT 1 FROM posts p WHERE p.topic_id = t.id AND p.content ILIKE $1
+				WHERE (COALESCE(t.search_text, LOWER(t.title)) LIKE $1 OR EXISTS (
+					SELECT 1 FROM posts p WHERE p.topic_id = t.id AND COALESCE(p.search_text, LOWER(COALESCE(p.content_text, p.content))) LIKE $1
 				)) %s
 				ORDER BY t.created_at DESC
 				LIMIT $%d OFFSET $%d
@@ -535,7 +839,7 @@ func (r *DBRepository) Search(ctx context.Context, query string, searchType stri
 					scanArgs := []interface{}{
 						&t.ID, &t.Title, &t.ForumID, &t.ForumName,
 						&t.AuthorID, &t.AuthorName,
-						&t.ReplyCount, &t.ViewCount,
+						&t.ReplyCount, &t.UpstreamReplyCount, &t.ViewCount,
 						&lastPostID, &lastPostAt,
 						&t.CreatedAt, &t.UpdatedAt,
 					}
@@ -563,21 +867,17 @@ func (r *DBRepository) Search(ctx context.Context, query string, searchType stri
 		querySQL := fmt.Sprintf(`
 			SELECT COUNT(*) FROM posts p
 			JOIN topics t ON p.topic_id = t.id
-			WHERE p.content ILIKE $1 %s
+			WHERE COALESCE(p.search_text, LOWER(COALESCE(p.content_text, p.content))) LIKE $1 %s
 		`, postForumClause)
 		var postTotal int
 		err := r.db.QueryRowContext(ctx, querySQL, args...).Scan(&postTotal)
 		if err == nil {
 			searchQuery := fmt.Sprintf(`
-				SELECT 
-					p.id, p.topic_id, t.title as topic_title,
-					p.author_id, u.username as author_name,
-					p.content, p.is_first_post,
-					p.created_at, p.updated_at
+				SELECT ` + postColumns + `
 				FROM posts p
 				JOIN topics t ON p.topic_id = t.id
 				JOIN users u ON p.author_id = u.id
-				WHERE p.content ILIKE $1 %s
+				WHERE COALESCE(p.search_text, LOWER(COALESCE(p.content_text, p.content))) LIKE $1 %s
 				ORDER BY p.created_at DESC
 				LIMIT $%d OFFSET $%d
 			`, postForumClause, argPos, argPos+1)
@@ -587,14 +887,8 @@ func (r *DBRepository) Search(ctx context.Context, query string, searchType stri
 			if err == nil {
 				defer rows.Close()
 				for rows.Next() {
-					var p models.Post
-					if err := rows.Scan(
-						&p.ID, &p.TopicID, &p.TopicTitle,
-						&p.AuthorID, &p.AuthorName,
-						&p.Content, &p.IsFirstPost,
-						&p.CreatedAt, &p.UpdatedAt,
-					); err == nil {
-						results.Posts = append(results.Posts, p)
+					if p, err := scanPost(rows); err == nil {
+						results.Posts = append(results.Posts, *p)
 					}
 				}
 			}
@@ -604,14 +898,14 @@ func (r *DBRepository) Search(ctx context.Context, query string, searchType stri
 
 	// Search users
 	if searchType == "all" || searchType == "users" {
-		querySQL := "SELECT COUNT(*) FROM users WHERE username ILIKE $1"
+		querySQL := "SELECT COUNT(*) FROM users WHERE COALESCE(search_text, LOWER(username)) LIKE $1"
 		var userTotal int
 		err := r.db.QueryRowContext(ctx, querySQL, args[0]).Scan(&userTotal)
 		if err == nil {
 			searchQuery := `
-				SELECT id, username, post_count, topic_count, registered_at, last_active_at
+				SELECT ` + userColumns + `
 				FROM users
-				WHERE username ILIKE $1
+				WHERE COALESCE(search_text, LOWER(username)) LIKE $1
 				ORDER BY username
 				LIMIT $2 OFFSET $3
 			`
@@ -619,16 +913,8 @@ func (r *DBRepository) Search(ctx context.Context, query string, searchType stri
 			if err == nil {
 				defer rows.Close()
 				for rows.Next() {
-					var u models.User
-					var lastActiveAt sql.NullTime
-					if err := rows.Scan(
-						&u.ID, &u.Username, &u.PostCount, &u.TopicCount,
-						&u.RegisteredAt, &lastActiveAt,
-					); err == nil {
-						if lastActiveAt.Valid {
-							u.LastActiveAt = &lastActiveAt.Time
-						}
-						results.Users = append(results.Users, u)
+					if u, err := scanUser(rows); err == nil {
+						results.Users = append(results.Users, *u)
 					}
 				}
 			}
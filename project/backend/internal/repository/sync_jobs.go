@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"forum-api-wrapper/internal/models"
+	"strings"
+)
+
+const syncJobColumns = `id, scope, target_id, trigger, status, started_at, finished_at, pages_fetched, rows_upserted,
+	counters_corrected, errors`
+
+// CreateSyncJob inserts a sync job and sets its ID
+func (r *DBRepository) CreateSyncJob(ctx context.Context, job *models.SyncJob) error {
+	query := `
+		INSERT INTO sync_jobs (scope, target_id, trigger, status, started_at, pages_fetched, rows_upserted, errors)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		job.Scope, job.TargetID, job.Trigger, job.Status, job.StartedAt,
+		job.PagesFetched, job.RowsUpserted, joinJobErrors(job.Errors),
+	).Scan(&job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create sync job: %w", err)
+	}
+	return nil
+}
+
+// UpdateSyncJob stores the progress and outcome of a sync job
+func (r *DBRepository) UpdateSyncJob(ctx context.Context, job *models.SyncJob) error {
+	query := `
+		UPDATE sync_jobs
+		SET status = $1, finished_at = $2, pages_fetched = $3, rows_upserted = $4,
+			counters_corrected = $5, errors = $6
+		WHERE id = $7
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		job.Status, job.FinishedAt, job.PagesFetched, job.RowsUpserted,
+		job.CountersCorrected, joinJobErrors(job.Errors), job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update sync job %d: %w", job.ID, err)
+	}
+	return nil
+}
+
+// GetSyncJobs retrieves sync jobs, most recent first
+func (r *DBRepository) GetSyncJobs(ctx context.Context, page, limit int) ([]models.SyncJob, int, error) {
+	offset := (page - 1) * limit
+
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sync_jobs").Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count sync jobs: %w", err)
+	}
+
+	query := `SELECT ` + syncJobColumns + `
+		FROM sync_jobs
+		ORDER BY started_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query sync jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.SyncJob
+	for rows.Next() {
+		job, err := scanSyncJob(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan sync job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+
+	return jobs, total, nil
+}
+
+// GetSyncJobByID retrieves a sync job by ID
+func (r *DBRepository) GetSyncJobByID(ctx context.Context, id int) (*models.SyncJob, error) {
+	query := `SELECT ` + syncJobColumns + ` FROM sync_jobs WHERE id = $1`
+
+	job, err := scanSyncJob(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync job: %w", err)
+	}
+	return job, nil
+}
+
+// scanSyncJob scans a row selected with syncJobColumns
+func scanSyncJob(row interface{ Scan(dest ...any) error }) (*models.SyncJob, error) {
+	var job models.SyncJob
+	var targetID sql.NullInt64
+	var finishedAt sql.NullTime
+	var errs sql.NullString
+	err := row.Scan(
+		&job.ID, &job.Scope, &targetID, &job.Trigger, &job.Status,
+		&job.StartedAt, &finishedAt, &job.PagesFetched, &job.RowsUpserted,
+		&job.CountersCorrected, &errs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if targetID.Valid {
+		id := int(targetID.Int64)
+		job.TargetID = &id
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	job.Errors = []string{}
+	if errs.Valid && errs.String != "" {
+		job.Errors = strings.Split(errs.String, "\n")
+	}
+	return &job, nil
+}
+
+// joinJobErrors stores job errors one per line, or NULL when there are none
+func joinJobErrors(errs []string) *string {
+	if len(errs) == 0 {
+		return nil
+	}
+	joined := strings.Join(errs, "\n")
+	return &joined
+}
@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Job is a task run periodically by the scheduler
+type Job struct {
+	// Name identifies the job in logs
+	Name string
+	// Interval is the time between the starts of two runs
+	Interval time.Duration
+	// Jitter adds a random delay of up to this much to every wait, so jobs
+	// started together drift apart and do not hit the forum in lockstep
+	Jitter time.Duration
+	// Immediate runs the job right after Start instead of waiting one interval
+	Immediate bool
+	// Run does the work; ctx is cancelled when the scheduler stops
+	Run func(ctx context.Context) error
+}
+
+// ErrSkipped is returned by a run that did not start because other work covering
+// it is in progress; the run is logged as skipped rather than failed
+var ErrSkipped = errors.New("skipped")
+
+// Scheduler runs jobs on their intervals. A job is never run concurrently with
+// itself: a run that comes due while the previous one is still going is skipped.
+type Scheduler struct {
+	jobs   []Job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	rand   *rand.Rand
+}
+
+// NewScheduler creates a scheduler for the given jobs; nothing runs until Start
+func NewScheduler(jobs ...Job) *Scheduler {
+	return &Scheduler{
+		jobs: jobs,
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Start begins running jobs in the background until ctx is done or Stop is called
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, job := range s.jobs {
+		if job.Interval <= 0 {
+			log.Printf("scheduler: job %s has no interval, not scheduling it", job.Name)
+			continue
+		}
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// loop triggers one job until ctx is done
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	var running atomic.Bool
+	var runs sync.WaitGroup
+	defer runs.Wait()
+
+	wait := job.Interval
+	if job.Immediate {
+		wait = 0
+	}
+	timer := time.NewTimer(wait + s.jitter(job.Jitter))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if running.CompareAndSwap(false, true) {
+			runs.Add(1)
+			go func() {
+				defer runs.Done()
+				defer running.Store(false)
+				s.run(ctx, job)
+			}()
+		} else {
+			log.Printf("scheduler: skipping %s, previous run still in progress", job.Name)
+		}
+
+		timer.Reset(job.Interval + s.jitter(job.Jitter))
+	}
+}
+
+// run executes a single run of job, logging its outcome
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	started := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("scheduler: %s panicked: %v", job.Name, r)
+		}
+	}()
+
+	if err := job.Run(ctx); err != nil {
+		if errors.Is(err, ErrSkipped) {
+			log.Printf("scheduler: skipping %s: %v", job.Name, err)
+			return
+		}
+		if ctx.Err() != nil {
+			log.Printf("scheduler: %s cancelled after %s", job.Name, time.Since(started).Round(time.Millisecond))
+			return
+		}
+		log.Printf("scheduler: %s failed after %s: %v", job.Name, time.Since(started).Round(time.Millisecond), err)
+		return
+	}
+	log.Printf("scheduler: %s finished in %s", job.Name, time.Since(started).Round(time.Millisecond))
+}
+
+// jitter returns a random duration in [0, max)
+func (s *Scheduler) jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Duration(s.rand.Int63n(int64(max)))
+}
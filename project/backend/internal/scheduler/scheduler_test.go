@@ -0,0 +1,189 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler_RunsJobRepeatedly(t *testing.T) {
+	runs := make(chan struct{}, 10)
+	s := NewScheduler(Job{
+		Name:      "tick",
+		Interval:  5 * time.Millisecond,
+		Immediate: true,
+		Run: func(ctx context.Context) error {
+			runs <- struct{}{}
+			return nil
+		},
+	})
+	s.Start(context.Background())
+	defer s.Stop()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-runs:
+		case <-time.After(time.Second):
+			t.Fatalf("job ran %d times, want 3", i)
+		}
+	}
+}
+
+func TestScheduler_SkipsRunWhilePreviousIsGoing(t *testing.T) {
+	var active, maxActive, started atomic.Int32
+	release := make(chan struct{})
+	s := NewScheduler(Job{
+		Name:      "slow",
+		Interval:  time.Millisecond,
+		Immediate: true,
+		Run: func(ctx context.Context) error {
+			started.Add(1)
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				m := maxActive.Load()
+				if n <= m || maxActive.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			<-release
+			return nil
+		},
+	})
+	s.Start(context.Background())
+
+	// Many intervals pass while the first run is blocked
+	time.Sleep(30 * time.Millisecond)
+	if got := started.Load(); got != 1 {
+		t.Errorf("started %d runs while the first was going, want 1", got)
+	}
+	close(release)
+	s.Stop()
+
+	if got := maxActive.Load(); got != 1 {
+		t.Errorf("max concurrent runs = %d, want 1", got)
+	}
+}
+
+func TestScheduler_StopCancelsAndWaits(t *testing.T) {
+	started := make(chan struct{})
+	var returned atomic.Bool
+	s := NewScheduler(Job{
+		Name:      "blocking",
+		Interval:  time.Hour,
+		Immediate: true,
+		Run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			returned.Store(true)
+			return ctx.Err()
+		},
+	})
+	s.Start(context.Background())
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("job did not start")
+	}
+	s.Stop()
+
+	if !returned.Load() {
+		t.Error("Stop returned before the running job finished")
+	}
+}
+
+func TestScheduler_WaitsOneIntervalUnlessImmediate(t *testing.T) {
+	var runs atomic.Int32
+	s := NewScheduler(Job{
+		Name:     "nightly",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	})
+	s.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	s.Stop()
+
+	if got := runs.Load(); got != 0 {
+		t.Errorf("job ran %d times before its first interval, want 0", got)
+	}
+}
+
+// fakeSyncer records the forums synced by the jobs
+type fakeSyncer struct {
+	mu     sync.Mutex
+	forums []int
+}
+
+func (f *fakeSyncer) SyncForums(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeSyncer) SyncForum(ctx context.Context, forumID int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.forums = append(f.forums, forumID)
+	switch forumID {
+	case 2:
+		return errors.New("boom")
+	case 3:
+		return fmt.Errorf("%w: forum 3 is being synced", ErrSkipped)
+	}
+	return nil
+}
+
+func (f *fakeSyncer) FullSync(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeSyncer) SyncProfiles(ctx context.Context) error {
+	return nil
+}
+
+func TestSyncJobs(t *testing.T) {
+	syncer := &fakeSyncer{}
+	cfg := Config{
+		IndexInterval:     time.Hour,
+		HotForumsInterval: 5 * time.Minute,
+		HotForums:         []int{1, 2, 3},
+		Jitter:            0.1,
+	}
+
+	jobs := SyncJobs(syncer, cfg)
+	if len(jobs) != 2 {
+		t.Fatalf("got %d jobs, want 2 (full reconcile and profiles disabled)", len(jobs))
+	}
+	if jobs[0].Jitter != 6*time.Minute {
+		t.Errorf("index jitter = %s, want 6m", jobs[0].Jitter)
+	}
+
+	// A failing forum does not stop the other hot forums, and a skipped one is
+	// not a failure
+	err := jobs[1].Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "forum 2") {
+		t.Errorf("expected the failure of forum 2 to be reported, got %v", err)
+	}
+	if err != nil && strings.Contains(err.Error(), "forum 3") {
+		t.Errorf("skipped forum 3 reported as failed: %v", err)
+	}
+	if len(syncer.forums) != 3 {
+		t.Errorf("synced forums %v, want [1 2 3]", syncer.forums)
+	}
+
+	// A run whose forums are all covered by running work is skipped
+	cfg.IndexInterval = 0
+	cfg.HotForums = []int{3}
+	jobs = SyncJobs(syncer, cfg)
+	if err := jobs[0].Run(context.Background()); !errors.Is(err, ErrSkipped) {
+		t.Errorf("run with every forum skipped returned %v, want ErrSkipped", err)
+	}
+}
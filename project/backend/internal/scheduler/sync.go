@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Syncer is the part of the scraper driven by the sync jobs
+type Syncer interface {
+	SyncForums(ctx context.Context) error
+	SyncForum(ctx context.Context, forumID int) error
+	FullSync(ctx context.Context) error
+	SyncProfiles(ctx context.Context) error
+}
+
+// Config sets how often each level of the forum is synced. A zero interval
+// disables that level.
+type Config struct {
+	// IndexInterval refreshes the forum index (forum names and counters)
+	IndexInterval time.Duration
+	// HotForumsInterval syncs the topics and new posts of HotForums
+	HotForumsInterval time.Duration
+	// HotForums are the IDs of busy forums that should stay fresh
+	HotForums []int
+	// FullInterval walks every page of every forum to catch what the other levels missed
+	FullInterval time.Duration
+	// ProfilesInterval refreshes a batch of stale user profiles
+	ProfilesInterval time.Duration
+	// Jitter is the fraction of each interval added at random to every wait
+	Jitter float64
+}
+
+// DefaultConfig returns the intervals used by the server
+func DefaultConfig() Config {
+	return Config{
+		IndexInterval:     time.Hour,
+		HotForumsInterval: 5 * time.Minute,
+		FullInterval:      24 * time.Hour,
+		ProfilesInterval:  6 * time.Hour,
+		Jitter:            0.1,
+	}
+}
+
+// SyncJobs builds the scheduler jobs that keep the mirror fresh through s. The
+// jobs overlap (the full walk covers the index and every forum), so s is
+// expected to refuse work already in progress with ErrSkipped.
+func SyncJobs(s Syncer, cfg Config) []Job {
+	jitter := func(interval time.Duration) time.Duration {
+		return time.Duration(float64(interval) * cfg.Jitter)
+	}
+
+	var jobs []Job
+	if cfg.IndexInterval > 0 {
+		jobs = append(jobs, Job{
+			Name:      "forum index",
+			Interval:  cfg.IndexInterval,
+			Jitter:    jitter(cfg.IndexInterval),
+			Immediate: true,
+			Run:       s.SyncForums,
+		})
+	}
+	if cfg.HotForumsInterval > 0 && len(cfg.HotForums) > 0 {
+		forumIDs := append([]int(nil), cfg.HotForums...)
+		jobs = append(jobs, Job{
+			Name:      "hot forums",
+			Interval:  cfg.HotForumsInterval,
+			Jitter:    jitter(cfg.HotForumsInterval),
+			Immediate: true,
+			Run: func(ctx context.Context) error {
+				var errs []error
+				skipped := 0
+				for _, forumID := range forumIDs {
+					err := s.SyncForum(ctx, forumID)
+					switch {
+					case err == nil:
+					case ctx.Err() != nil:
+						return ctx.Err()
+					case errors.Is(err, ErrSkipped):
+						// A running job covers the forum already
+						skipped++
+					default:
+						errs = append(errs, fmt.Errorf("forum %d: %w", forumID, err))
+					}
+				}
+				if skipped == len(forumIDs) {
+					return fmt.Errorf("%w: every hot forum is being synced", ErrSkipped)
+				}
+				return errors.Join(errs...)
+			},
+		})
+	}
+	if cfg.FullInterval > 0 {
+		jobs = append(jobs, Job{
+			Name:     "full reconcile",
+			Interval: cfg.FullInterval,
+			Jitter:   jitter(cfg.FullInterval),
+			Run:      s.FullSync,
+		})
+	}
+	if cfg.ProfilesInterval > 0 {
+		jobs = append(jobs, Job{
+			Name:     "user profiles",
+			Interval: cfg.ProfilesInterval,
+			Jitter:   jitter(cfg.ProfilesInterval),
+			Run:      s.SyncProfiles,
+		})
+	}
+	return jobs
+}
@@ -0,0 +1,135 @@
+package scraper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CachedPage is a previously fetched page together with its HTTP validators
+type CachedPage struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	ContentType  string    `json:"contentType,omitempty"`
+	StoredAt     time.Time `json:"storedAt"`
+	Body         []byte    `json:"-"`
+}
+
+// PageCache stores pages so they can be revalidated with conditional requests
+type PageCache interface {
+	// Get returns the cached page for url, or nil if there is none
+	Get(url string) (*CachedPage, error)
+	// Put stores or replaces the cached page for its URL
+	Put(page *CachedPage) error
+}
+
+// DiskCache is a PageCache keeping one metadata file and one gzipped body per URL
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a disk cache rooted at dir, creating the directory if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+func (c *DiskCache) paths(url string) (meta, body string) {
+	sum := sha256.Sum256([]byte(url))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, name+".json"), filepath.Join(c.dir, name+".html.gz")
+}
+
+// Get returns the cached page for url, or nil if there is none
+func (c *DiskCache) Get(url string) (*CachedPage, error) {
+	metaPath, bodyPath := c.paths(url)
+
+	meta, err := os.ReadFile(metaPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	var page CachedPage
+	if err := json.Unmarshal(meta, &page); err != nil {
+		return nil, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+
+	f, err := os.Open(bodyPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cached body: %w", err)
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached body: %w", err)
+	}
+	if page.Body, err = io.ReadAll(zr); err != nil {
+		return nil, fmt.Errorf("failed to read cached body: %w", err)
+	}
+
+	return &page, nil
+}
+
+// Put stores or replaces the cached page for its URL
+func (c *DiskCache) Put(page *CachedPage) error {
+	metaPath, bodyPath := c.paths(page.URL)
+
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	if _, err := zw.Write(page.Body); err != nil {
+		return fmt.Errorf("failed to compress body: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress body: %w", err)
+	}
+
+	meta, err := json.Marshal(page)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	// Body first: a metadata file is only ever written next to a complete body
+	if err := writeFileAtomic(bodyPath, body.Bytes()); err != nil {
+		return err
+	}
+	return writeFileAtomic(metaPath, meta)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it into place
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestDiskCache(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	miss, err := cache.Get("https://resql.ru/forum/")
+	if err != nil || miss != nil {
+		t.Fatalf("Expected a miss, got %v, %v", miss, err)
+	}
+
+	stored := &CachedPage{
+		URL:          "https://resql.ru/forum/",
+		ETag:         `"abc"`,
+		LastModified: "Mon, 20 May 2024 12:00:00 GMT",
+		ContentType:  "text/html; charset=windows-1251",
+		StoredAt:     time.Date(2024, time.May, 20, 12, 0, 0, 0, time.UTC),
+		Body:         []byte("<html>body</html>"),
+	}
+	if err := cache.Put(stored); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got, err := cache.Get("https://resql.ru/forum/")
+	if err != nil || got == nil {
+		t.Fatalf("Expected a hit, got %v, %v", got, err)
+	}
+	if got.ETag != stored.ETag || got.LastModified != stored.LastModified || got.ContentType != stored.ContentType {
+		t.Errorf("Unexpected validators %+v", got)
+	}
+	if string(got.Body) != string(stored.Body) || !got.StoredAt.Equal(stored.StoredAt) {
+		t.Errorf("Unexpected body %q stored at %v", got.Body, got.StoredAt)
+	}
+}
+
+func TestFetchPage_ConditionalRequest(t *testing.T) {
+	body, _ := charmap.Windows1251.NewEncoder().String("<p>Форум</p>")
+	var conditional []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditional = append(conditional, r.Header.Get("If-None-Match")+"|"+r.Header.Get("If-Modified-Since"))
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 20 May 2024 12:00:00 GMT")
+		w.Header().Set("Content-Type", "text/html; charset=windows-1251")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	cache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s := NewScraper(server.URL, nil, Options{IgnoreRobots: true, Cache: cache})
+	ctx := context.Background()
+
+	first, err := s.FetchPage(ctx, "/")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.Unchanged {
+		t.Error("Expected first fetch to be a full download")
+	}
+
+	// Validators are only kept once the page has been stored
+	if again, err := s.FetchPage(ctx, "/"); err != nil || again.Unchanged {
+		t.Fatalf("Expected a page not yet stored to be downloaded again, got %v", err)
+	}
+	first.stored()
+
+	second, err := s.FetchPage(ctx, "/")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !second.Unchanged || second.StatusCode != http.StatusNotModified {
+		t.Errorf("Expected second fetch to be unchanged, got status %d", second.StatusCode)
+	}
+	if string(second.Body) != "<p>Форум</p>" {
+		t.Errorf("Expected cached body decoded to UTF-8, got %q", second.Body)
+	}
+
+	want := []string{"|", "|", `"v1"|Mon, 20 May 2024 12:00:00 GMT`}
+	if len(conditional) != 3 || conditional[0] != want[0] || conditional[1] != want[1] || conditional[2] != want[2] {
+		t.Errorf("Expected conditional headers %q, got %q", want, conditional)
+	}
+}
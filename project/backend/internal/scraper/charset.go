@@ -0,0 +1,59 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// metaCharset matches both <meta charset="..."> and the http-equiv Content-Type form
+var metaCharset = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_:.\-]+)`)
+
+// metaPrescanLimit is how far into the document a <meta> charset declaration is looked for
+const metaPrescanLimit = 1024
+
+// detectEncoding determines a page's character encoding from, in order of
+// precedence, a byte order mark, the Content-Type header and a <meta> declaration.
+// Undeclared pages are treated as UTF-8 when they are valid UTF-8 and as
+// windows-1251, the forum's legacy encoding, otherwise.
+func detectEncoding(body []byte, contentType string) (encoding.Encoding, string) {
+	if e, name, certain := charset.DetermineEncoding(body, contentType); certain {
+		return e, name
+	}
+
+	head := body
+	if len(head) > metaPrescanLimit {
+		head = head[:metaPrescanLimit]
+	}
+	if m := metaCharset.FindSubmatch(head); m != nil {
+		if e, name := charset.Lookup(string(m[1])); e != nil {
+			return e, name
+		}
+	}
+
+	if utf8.Valid(body) {
+		return encoding.Nop, "utf-8"
+	}
+	return charmap.Windows1251, "windows-1251"
+}
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// decodeBody converts a page body to UTF-8. Bytes that cannot be decoded are
+// replaced with U+FFFD so the result is always safe to store.
+func decodeBody(body []byte, contentType string) ([]byte, error) {
+	e, name := detectEncoding(body, contentType)
+
+	decoded, err := e.NewDecoder().Bytes(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s body: %w", name, err)
+	}
+
+	decoded = bytes.TrimPrefix(decoded, utf8BOM)
+	return bytes.ToValidUTF8(decoded, []byte("\uFFFD")), nil
+}
@@ -0,0 +1,59 @@
+package scraper
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestDecodeBody(t *testing.T) {
+	cp1251, err := charmap.Windows1251.NewEncoder().String("Форум")
+	if err != nil {
+		t.Fatalf("Failed to encode fixture: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+	}{
+		{"utf-8 without declaration", []byte("<p>Форум</p>"), "text/html"},
+		{"utf-8 bom", append([]byte("\xef\xbb\xbf"), "<p>Форум</p>"...), ""},
+		{"charset header", []byte("<p>" + cp1251 + "</p>"), "text/html; charset=windows-1251"},
+		{"header overrides meta", []byte(`<meta charset="utf-8"><p>` + cp1251 + "</p>"), "text/html; charset=cp1251"},
+		{"meta charset", []byte(`<meta charset="windows-1251"><p>` + cp1251 + "</p>"), "text/html"},
+		{"meta http-equiv", []byte(`<meta http-equiv="Content-Type" content="text/html; charset=windows-1251"><p>` + cp1251 + "</p>"), ""},
+		{"undeclared legacy encoding", []byte("<p>" + cp1251 + "</p>"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := decodeBody(tt.body, tt.contentType)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !bytes.Contains(decoded, []byte("<p>Форум</p>")) {
+				t.Errorf("Expected decoded body to contain 'Форум', got %q", decoded)
+			}
+			if bytes.HasPrefix(decoded, utf8BOM) {
+				t.Error("Expected byte order mark to be stripped")
+			}
+		})
+	}
+}
+
+func TestParseForumIndex_Windows1251(t *testing.T) {
+	body, err := decodeBody(readFixture(t, "forum_index_cp1251.html"), "text/html")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	forums, err := parseForumIndex(body)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if forums[1].Description != "Oracle Database, PL/SQL" || forums[0].Description != "Вопросы по Microsoft SQL Server, T-SQL и администрированию" {
+		t.Errorf("Unexpected descriptions '%s' / '%s'", forums[0].Description, forums[1].Description)
+	}
+}
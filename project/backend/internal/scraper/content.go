@@ -0,0 +1,213 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"forum-api-wrapper/internal/models"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// bbCodeBlock matches literal [code] BBCode left unrendered in message bodies
+var bbCodeBlock = regexp.MustCompile(`(?is)\[code(?:=(\w+))?\](.*?)\[/code\]`)
+
+// expandBBCode rewrites literal [code]...[/code] blocks into <pre> elements so
+// they are handled like code the forum already rendered
+func expandBBCode(body []byte) []byte {
+	return bbCodeBlock.ReplaceAllFunc(body, func(m []byte) []byte {
+		sub := bbCodeBlock.FindSubmatch(m)
+		lang := string(sub[1])
+		return []byte(fmt.Sprintf(`<pre class="code" data-lang="%s">%s</pre>`, html.EscapeString(lang), sub[2]))
+	})
+}
+
+// renderContent converts a message body into the HTML subset we store for posts.
+// Quotes become <blockquote> (with the quoted author in <cite>) and code blocks
+// become <pre><code> with whitespace preserved; other markup is reduced to
+// basic inline formatting, links, lists and line breaks.
+func renderContent(body *nethtml.Node) string {
+	var b bytes.Buffer
+	renderChildren(&b, body)
+	return strings.TrimSpace(trailingBreaks.ReplaceAllString(b.String(), ""))
+}
+
+var trailingBreaks = regexp.MustCompile(`(\s*<br>)+\s*$`)
+
+var inlineTags = map[string]string{
+	"b": "strong", "strong": "strong",
+	"i": "em", "em": "em",
+	"u": "u",
+	"s": "s", "strike": "s", "del": "s",
+	"p": "p", "ul": "ul", "ol": "ol", "li": "li",
+	"sub": "sub", "sup": "sup",
+}
+
+func renderChildren(b *bytes.Buffer, n *nethtml.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		renderNode(b, c)
+	}
+}
+
+func renderNode(b *bytes.Buffer, n *nethtml.Node) {
+	switch n.Type {
+	case nethtml.TextNode:
+		b.WriteString(html.EscapeString(collapseSpace(n.Data)))
+		return
+	case nethtml.ElementNode:
+	default:
+		return
+	}
+
+	switch {
+	case n.Data == "script" || n.Data == "style":
+		return
+	case n.Data == "pre" || hasClass(n, "code"):
+		renderCode(b, n)
+	case n.Data == "blockquote" || hasClass(n, "quote"):
+		renderQuote(b, n)
+	case n.Data == "br":
+		b.WriteString("<br>")
+	case n.Data == "a":
+		href := attr(n, "href")
+		if !safeHref(href) {
+			renderChildren(b, n)
+			return
+		}
+		fmt.Fprintf(b, `<a href="%s">`, html.EscapeString(href))
+		renderChildren(b, n)
+		b.WriteString("</a>")
+	case n.Data == "img":
+		b.WriteString(html.EscapeString(attr(n, "alt")))
+	case inlineTags[n.Data] != "":
+		tag := inlineTags[n.Data]
+		fmt.Fprintf(b, "<%s>", tag)
+		renderChildren(b, n)
+		fmt.Fprintf(b, "</%s>", tag)
+	case n.Data == "div" || n.Data == "tr":
+		renderChildren(b, n)
+		b.WriteString("<br>")
+	default:
+		renderChildren(b, n)
+	}
+}
+
+// renderCode writes a code block, keeping its text verbatim
+func renderCode(b *bytes.Buffer, n *nethtml.Node) {
+	var code strings.Builder
+	var walk func(*nethtml.Node)
+	walk = func(node *nethtml.Node) {
+		switch {
+		case node.Type == nethtml.TextNode:
+			code.WriteString(node.Data)
+		case node.Type == nethtml.ElementNode && node.Data == "br":
+			code.WriteByte('\n')
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if node != n && node.Type == nethtml.ElementNode && (node.Data == "tr" || node.Data == "div" || node.Data == "li") {
+			code.WriteByte('\n')
+		}
+	}
+	walk(n)
+
+	text := strings.Trim(strings.ReplaceAll(code.String(), "\r\n", "\n"), "\n")
+	if lang := attr(n, "data-lang"); lang != "" {
+		fmt.Fprintf(b, `<pre><code class="language-%s">`, html.EscapeString(strings.ToLower(lang)))
+	} else {
+		b.WriteString("<pre><code>")
+	}
+	b.WriteString(html.EscapeString(text))
+	b.WriteString("</code></pre>")
+}
+
+// renderQuote writes a quote block; a "quotetitle" header becomes the <cite>
+func renderQuote(b *bytes.Buffer, n *nethtml.Node) {
+	b.WriteString("<blockquote>")
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == nethtml.ElementNode && hasClass(c, "quotetitle") {
+			if author := quoteAuthor(c); author != "" {
+				fmt.Fprintf(b, "<cite>%s</cite>", html.EscapeString(author))
+			}
+			continue
+		}
+		renderNode(b, c)
+	}
+	body := b.Bytes()
+	b.Truncate(len(trailingBreaks.ReplaceAll(body, nil)))
+	b.WriteString("</blockquote>")
+}
+
+// postAnchor matches the "#<postID>" fragment of a link to a single post
+var postAnchor = regexp.MustCompile(`#(?:msg)?(\d+)$`)
+
+// parseQuotes lists the quotes of a message body in order. Only top-level quotes
+// count: a quote nested in another one was made by the quoted post. A header
+// linking to the quoted post identifies it; otherwise only the author is known.
+func parseQuotes(body *nethtml.Node) []models.PostQuote {
+	var quotes []models.PostQuote
+	var walk func(*nethtml.Node)
+	walk = func(n *nethtml.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != nethtml.ElementNode {
+				continue
+			}
+			if c.Data != "blockquote" && !hasClass(c, "quote") {
+				walk(c)
+				continue
+			}
+			var header *nethtml.Node
+			for h := c.FirstChild; h != nil && header == nil; h = h.NextSibling {
+				if h.Type == nethtml.ElementNode && hasClass(h, "quotetitle") {
+					header = h
+				}
+			}
+			if header == nil {
+				continue
+			}
+			var quote models.PostQuote
+			quote.QuotedAuthor = quoteAuthor(header)
+			if link := findFirst(header, isElement("a")); link != nil {
+				if m := postAnchor.FindStringSubmatch(attr(link, "href")); m != nil {
+					quote.QuotedPostID, _ = strconv.Atoi(m[1])
+				}
+			}
+			if quote.QuotedPostID != 0 || quote.QuotedAuthor != "" {
+				quotes = append(quotes, quote)
+			}
+		}
+	}
+	walk(body)
+	return quotes
+}
+
+// quoteAuthor extracts the author name from a quote header such as "ivanov писал(а):"
+func quoteAuthor(header *nethtml.Node) string {
+	name := textContent(header)
+	name = strings.TrimSpace(strings.TrimSuffix(name, ":"))
+	name = strings.TrimSpace(strings.TrimSuffix(name, "писал(а)"))
+	return name
+}
+
+// safeHref reports whether a link target can be kept as-is
+func safeHref(href string) bool {
+	lower := strings.ToLower(strings.TrimSpace(href))
+	if lower == "" {
+		return false
+	}
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "/") {
+		return true
+	}
+	return !strings.Contains(lower, ":")
+}
+
+var spaceRun = regexp.MustCompile(`\s+`)
+
+func collapseSpace(s string) string {
+	return spaceRun.ReplaceAllString(s, " ")
+}
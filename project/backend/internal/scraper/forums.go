@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+
+	"forum-api-wrapper/internal/models"
+)
+
+// forumIndexPath is the path of the forum index page relative to baseURL
+const forumIndexPath = "/"
+
+// parseForumIndex extracts all forum categories from the forum index page.
+// Each forum is a table row with class "forumrow"; the upstream ID comes from
+// the fid parameter of the forum link.
+func parseForumIndex(body []byte) ([]models.Forum, error) {
+	doc, err := parseHTML(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var forums []models.Forum
+	seen := make(map[int]bool)
+	for _, row := range findAll(doc, withClass("forumrow")) {
+		cell := findFirst(row, withClass("forumname"))
+		if cell == nil {
+			continue
+		}
+		link, id := linkWithParam(cell, "fid")
+		if link == nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		f := models.Forum{
+			ID:   id,
+			Name: textContent(link),
+		}
+		if desc := findFirst(cell, withClass("forumdesc")); desc != nil {
+			f.Description = textContent(desc)
+		}
+		if topics := findFirst(row, withClass("topics")); topics != nil {
+			f.UpstreamTopicCount = parseCount(textContent(topics))
+		}
+		if posts := findFirst(row, withClass("posts")); posts != nil {
+			f.UpstreamPostCount = parseCount(textContent(posts))
+		}
+		forums = append(forums, f)
+	}
+
+	if len(forums) == 0 {
+		return nil, fmt.Errorf("%w: no forums found on index page", ErrUnexpectedMarkup)
+	}
+	return forums, nil
+}
+
+// SyncForums fetches the forum index and upserts every forum it lists.
+// Nothing is written when the index has not changed since the last fetch and
+// every forum it lists is stored.
+func (s *Scraper) SyncForums(ctx context.Context) error {
+	page, err := s.FetchPage(ctx, forumIndexPath)
+	if err != nil {
+		return fmt.Errorf("failed to fetch forum index: %w", err)
+	}
+	if page.Unchanged {
+		stored, err := s.forumsStored(ctx, page)
+		if err != nil || stored {
+			return err
+		}
+	}
+
+	err = s.ingestForumIndex(ctx, page)
+	if quarantined, qErr := s.quarantineParseError(ctx, err); quarantined {
+		return qErr
+	}
+	if err != nil {
+		return err
+	}
+	page.stored()
+	return nil
+}
+
+// forumsStored reports whether every forum listed on an index page is stored.
+// An index that does not parse is reported as not stored, so it is ingested
+// and quarantined.
+func (s *Scraper) forumsStored(ctx context.Context, page *Page) (bool, error) {
+	forums, err := parseForumIndex(page.Body)
+	if err != nil {
+		return false, nil
+	}
+	for _, f := range forums {
+		stored, err := s.repo.GetForumByID(ctx, f.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to load forum %d: %w", f.ID, err)
+		}
+		if stored == nil {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ingestForumIndex parses the forum index and upserts every forum it lists
+func (s *Scraper) ingestForumIndex(ctx context.Context, page *Page) error {
+	forums, err := parseForumIndex(page.Body)
+	if err != nil {
+		return &ParseError{Page: page, Err: fmt.Errorf("failed to parse forum index: %w", err)}
+	}
+
+	if err := s.repo.UpsertForums(ctx, forums); err != nil {
+		return fmt.Errorf("failed to store forums: %w", err)
+	}
+	countRows(ctx, len(forums))
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package scraper
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("Failed to read fixture %s: %v", name, err)
+	}
+	return body
+}
+
+func TestParseForumIndex(t *testing.T) {
+	forums, err := parseForumIndex(readFixture(t, "forum_index.html"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(forums) != 3 {
+		t.Fatalf("Expected 3 forums, got %d", len(forums))
+	}
+
+	mssql := forums[0]
+	if mssql.ID != 1 {
+		t.Errorf("Expected forum ID 1, got %d", mssql.ID)
+	}
+	if mssql.Name != "Microsoft SQL Server" {
+		t.Errorf("Expected forum name 'Microsoft SQL Server', got '%s'", mssql.Name)
+	}
+	if mssql.Description != "Вопросы по Microsoft SQL Server, T-SQL и администрированию" {
+		t.Errorf("Unexpected description '%s'", mssql.Description)
+	}
+	if mssql.UpstreamTopicCount != 28412 || mssql.UpstreamPostCount != 341903 {
+		t.Errorf("Expected counters 28412/341903, got %d/%d", mssql.UpstreamTopicCount, mssql.UpstreamPostCount)
+	}
+
+	if forums[2].ID != 16 || forums[2].Description != "" {
+		t.Errorf("Expected forum 16 with empty description, got %d '%s'", forums[2].ID, forums[2].Description)
+	}
+}
+
+func TestParseForumIndex_UnexpectedMarkup(t *testing.T) {
+	_, err := parseForumIndex([]byte("<html><body><p>Сайт на обслуживании</p></body></html>"))
+	if !errors.Is(err, ErrUnexpectedMarkup) {
+		t.Fatalf("Expected ErrUnexpectedMarkup, got %v", err)
+	}
+}
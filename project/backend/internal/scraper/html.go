@@ -0,0 +1,170 @@
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// parseHTML parses a page body into a node tree
+func parseHTML(body []byte) (*html.Node, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html: %w", err)
+	}
+	return doc, nil
+}
+
+// findAll returns all descendants of n (including n) matching match, in document order
+func findAll(n *html.Node, match func(*html.Node) bool) []*html.Node {
+	var found []*html.Node
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if match(node) {
+			found = append(found, node)
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return found
+}
+
+// findFirst returns the first descendant of n matching match, or nil
+func findFirst(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findFirst(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// isElement matches element nodes with the given tag name
+func isElement(tag string) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == tag
+	}
+}
+
+// withClass matches element nodes carrying the given CSS class
+func withClass(class string) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		return n.Type == html.ElementNode && hasClass(n, class)
+	}
+}
+
+// attr returns the value of an attribute, or an empty string
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasClass reports whether the element's class attribute contains class
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if strings.EqualFold(c, class) {
+			return true
+		}
+	}
+	return false
+}
+
+// textContent returns the whitespace-collapsed text of a node and its descendants
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			b.WriteString(node.Data)
+			b.WriteByte(' ')
+		}
+		for c := node.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// queryParamID extracts a numeric query parameter (e.g. fid, tid, uid) from a link
+func queryParamID(href, param string) (int, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return 0, false
+	}
+	id, err := strconv.Atoi(u.Query().Get(param))
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// linkWithParam returns the first link under n whose href carries param
+func linkWithParam(n *html.Node, param string) (*html.Node, int) {
+	for _, a := range findAll(n, isElement("a")) {
+		if id, ok := queryParamID(attr(a, "href"), param); ok {
+			return a, id
+		}
+	}
+	return nil, 0
+}
+
+var nonDigits = regexp.MustCompile(`\D+`)
+
+// parseCount parses counters such as "28 412" or "1,204"; missing values count as zero
+func parseCount(s string) int {
+	n, err := strconv.Atoi(nonDigits.ReplaceAllString(s, ""))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// firstText returns the first non-blank text directly or indirectly under n
+func firstText(n *html.Node) string {
+	text := findFirst(n, func(node *html.Node) bool {
+		return node.Type == html.TextNode && strings.TrimSpace(node.Data) != ""
+	})
+	if text == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(text.Data), " ")
+}
+
+// lastPageNumber returns the highest page number linked from the pager, or 1 if there is none
+func lastPageNumber(doc *html.Node) int {
+	last := 1
+	pager := findFirst(doc, withClass("pager"))
+	if pager == nil {
+		return last
+	}
+	for _, a := range findAll(pager, isElement("a")) {
+		if p, ok := queryParamID(attr(a, "href"), "p"); ok && p > last {
+			last = p
+		}
+	}
+	return last
+}
+
+// pageCount parses only the pager of a page and returns its last page number
+func pageCount(body []byte) (int, error) {
+	doc, err := parseHTML(body)
+	if err != nil {
+		return 0, err
+	}
+	return lastPageNumber(doc), nil
+}
@@ -0,0 +1,209 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HTTPSource fetches pages from the live forum. Requests are spaced per host,
+// robots.txt is honoured, transient failures are retried with backoff and,
+// with a cache configured, pages are revalidated with conditional requests.
+type HTTPSource struct {
+	baseURL    string
+	httpClient *http.Client
+	opts       Options
+	limiter    *hostLimiter
+	robots     *robotsCache
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// NewHTTPSource creates a page source for the forum at baseURL. It uses the
+// crawling options of opts: user agent, rate limit, robots, retries and cache.
+func NewHTTPSource(baseURL string, opts Options) *HTTPSource {
+	return &HTTPSource{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		opts:    opts,
+		limiter: newHostLimiter(opts.RequestsPerSecond, opts.Burst),
+		robots:  &robotsCache{entries: make(map[string]robotsEntry)},
+		sleep:   sleepContext,
+	}
+}
+
+// userAgent returns the configured User-Agent or the default one
+func (h *HTTPSource) userAgent() string {
+	if h.opts.UserAgent != "" {
+		return h.opts.UserAgent
+	}
+	return DefaultUserAgent
+}
+
+// Fetch fetches a page from the forum. Paths disallowed by robots.txt are
+// refused with ErrDisallowed. Transient failures are retried up to
+// Options.MaxAttempts times; failures are reported as *FetchError.
+func (h *HTTPSource) Fetch(ctx context.Context, path string) (*Response, error) {
+	pageURL, err := url.Parse(h.baseURL + path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page URL: %w", err)
+	}
+
+	if !h.opts.IgnoreRobots {
+		policy, err := h.robotsPolicy(ctx, pageURL)
+		if err != nil {
+			return nil, err
+		}
+		if !policy.Allowed(pageURL.RequestURI()) {
+			return nil, fmt.Errorf("%w: %s", ErrDisallowed, pageURL.RequestURI())
+		}
+	}
+
+	var resp *Response
+	err = h.withRetries(ctx, func() error {
+		var err error
+		resp, err = h.fetchOnce(ctx, pageURL)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// withRetries calls attempt until it succeeds, fails with anything but a
+// temporary *FetchError or Options.MaxAttempts is reached, backing off between
+// attempts. The last failure is returned.
+func (h *HTTPSource) withRetries(ctx context.Context, attempt func() error) error {
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+
+		var fetchErr *FetchError
+		if !errors.As(err, &fetchErr) {
+			return err
+		}
+		fetchErr.Attempts = n
+		if !fetchErr.Temporary() || n >= h.opts.MaxAttempts {
+			return fetchErr
+		}
+
+		delay, ok := h.retryDelay(n, fetchErr.RetryAfter)
+		if !ok {
+			return fetchErr
+		}
+		if err := h.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// fetchOnce performs a single GET of pageURL, made conditional when the page is cached
+func (h *HTTPSource) fetchOnce(ctx context.Context, pageURL *url.URL) (*Response, error) {
+	if err := h.limiter.Wait(ctx, pageURL.Host); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", h.userAgent())
+
+	cached := h.cachedPage(pageURL.String())
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	fetchedAt := time.Now()
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &FetchError{URL: pageURL.String(), Err: err}
+	}
+	defer resp.Body.Close()
+
+	page := &Response{
+		URL:        pageURL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		FetchedAt:  fetchedAt,
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		// A 304 carries no body, so its charset comes from the cached response
+		page.Header = resp.Header.Clone()
+		page.Header.Set("Content-Type", cached.ContentType)
+		page.Body = cached.Body
+		page.Unchanged = true
+		return page, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &FetchError{
+			URL:        pageURL.String(),
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			Err:        fmt.Errorf("unexpected status code: %d", resp.StatusCode),
+		}
+	}
+
+	if page.Body, err = io.ReadAll(resp.Body); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &FetchError{URL: pageURL.String(), Err: fmt.Errorf("failed to read response body: %w", err)}
+	}
+	page.commit = func() { h.storeCachedPage(page) }
+
+	return page, nil
+}
+
+// cachedPage returns the cached copy of url, if caching is enabled and the page is cached.
+// The cache is best effort: read failures are logged and treated as a miss.
+func (h *HTTPSource) cachedPage(url string) *CachedPage {
+	if h.opts.Cache == nil {
+		return nil
+	}
+	cached, err := h.opts.Cache.Get(url)
+	if err != nil {
+		log.Printf("scraper: page cache read for %s failed: %v", url, err)
+		return nil
+	}
+	return cached
+}
+
+// storeCachedPage caches a freshly fetched page when the server sent validators
+// for it. It runs once the scraper has stored the page, see Response.commit.
+func (h *HTTPSource) storeCachedPage(page *Response) {
+	etag, lastModified := page.Header.Get("ETag"), page.Header.Get("Last-Modified")
+	if h.opts.Cache == nil || (etag == "" && lastModified == "") {
+		return
+	}
+	err := h.opts.Cache.Put(&CachedPage{
+		URL:          page.URL,
+		ETag:         etag,
+		LastModified: lastModified,
+		ContentType:  page.Header.Get("Content-Type"),
+		StoredAt:     page.FetchedAt,
+		Body:         page.Body,
+	})
+	if err != nil {
+		log.Printf("scraper: page cache write for %s failed: %v", page.URL, err)
+	}
+}
@@ -0,0 +1,93 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"forum-api-wrapper/internal/models"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// DefaultForumHosts are the hosts of the live forum, whose topic links are
+// resolved to mirrored topics
+var DefaultForumHosts = []string{"resql.ru", "www.resql.ru"}
+
+// forumHostSet returns the hosts whose links point at the forum: the configured
+// ones, or DefaultForumHosts, and the host of the base URL pages are fetched from
+func forumHostSet(baseURL string, hosts []string) map[string]bool {
+	if hosts == nil {
+		hosts = DefaultForumHosts
+	}
+	set := make(map[string]bool, len(hosts)+1)
+	for _, h := range hosts {
+		set[strings.ToLower(h)] = true
+	}
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" {
+		set[strings.ToLower(u.Host)] = true
+	}
+	return set
+}
+
+// parseLinks lists the links of stored post HTML in order, without duplicates.
+// Links to forum topics, relative ones or those on one of hosts, are resolved
+// to the linked topic and post.
+func parseLinks(content string, hosts map[string]bool) []models.PostLink {
+	nodes, err := nethtml.ParseFragment(strings.NewReader(content), &nethtml.Node{Type: nethtml.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		return nil
+	}
+
+	var links []models.PostLink
+	seen := make(map[string]bool)
+	for _, n := range nodes {
+		for _, a := range findAll(n, isElement("a")) {
+			href := attr(a, "href")
+			if href == "" || seen[href] {
+				continue
+			}
+			seen[href] = true
+			link := models.PostLink{URL: href}
+			link.TargetTopicID, link.TargetPostID = topicLinkTarget(href, hosts)
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// topicLinkTarget returns the topic and post a link points at; both are nil
+// when it is not a link to a forum topic
+func topicLinkTarget(href string, hosts map[string]bool) (*int, *int) {
+	u, err := url.Parse(href)
+	if err != nil || (u.Host != "" && !hosts[strings.ToLower(u.Host)]) {
+		return nil, nil
+	}
+	if path.Base(u.Path) != "topic.php" {
+		return nil, nil
+	}
+	topicID, ok := queryParamID(href, "tid")
+	if !ok {
+		return nil, nil
+	}
+	if m := postAnchor.FindStringSubmatch(href); m != nil {
+		if postID, err := strconv.Atoi(m[1]); err == nil && postID > 0 {
+			return &topicID, &postID
+		}
+	}
+	return &topicID, nil
+}
+
+// storeLinks records the links in a post's content
+func (s *Scraper) storeLinks(ctx context.Context, post *models.Post) error {
+	links := parseLinks(post.Content, s.forumHosts)
+	if err := s.repo.ReplacePostLinks(ctx, post.ID, links); err != nil {
+		return fmt.Errorf("failed to store links of post %d: %w", post.ID, err)
+	}
+	countRows(ctx, len(links))
+	return nil
+}
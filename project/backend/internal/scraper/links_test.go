@@ -0,0 +1,46 @@
+package scraper
+
+import "testing"
+
+func TestParseLinks(t *testing.T) {
+	hosts := forumHostSet("http://127.0.0.1:8080", nil)
+	content := `См. <a href="/forum/topic.php?tid=1001#500002">ответ</a>, ` +
+		`<a href="https://resql.ru/forum/topic.php?tid=1001&amp;p=2">вторую страницу</a>, ` +
+		`<a href="http://127.0.0.1:8080/topic.php?tid=7">зеркало</a>, ` +
+		`<a href="https://example.com/topic.php?tid=1">чужой форум</a>, ` +
+		`<a href="/forum/forum.php?fid=1">раздел</a> и ` +
+		`<a href="/forum/topic.php?tid=1001#500002">снова ответ</a>.`
+
+	links := parseLinks(content, hosts)
+
+	tests := []struct {
+		url   string
+		topic int
+		post  int
+	}{
+		{"/forum/topic.php?tid=1001#500002", 1001, 500002},
+		{"https://resql.ru/forum/topic.php?tid=1001&p=2", 1001, 0},
+		{"http://127.0.0.1:8080/topic.php?tid=7", 7, 0},
+		{"https://example.com/topic.php?tid=1", 0, 0},
+		{"/forum/forum.php?fid=1", 0, 0},
+	}
+	if len(links) != len(tests) {
+		t.Fatalf("Expected %d links, got %d: %+v", len(tests), len(links), links)
+	}
+	for i, tt := range tests {
+		link := links[i]
+		if link.URL != tt.url {
+			t.Errorf("Link %d: expected URL %s, got %s", i, tt.url, link.URL)
+		}
+		topic, post := 0, 0
+		if link.TargetTopicID != nil {
+			topic = *link.TargetTopicID
+		}
+		if link.TargetPostID != nil {
+			post = *link.TargetPostID
+		}
+		if topic != tt.topic || post != tt.post {
+			t.Errorf("Link %s: expected topic %d post %d, got topic %d post %d", tt.url, tt.topic, tt.post, topic, post)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// progressLogInterval is how often a batch logs its progress when Options.OnProgress is unset
+const progressLogInterval = 30 * time.Second
+
+// Progress is the state of a running batch of topics or profiles
+type Progress struct {
+	Total   int
+	Done    int
+	Failed  int
+	Elapsed time.Duration
+}
+
+// SyncTopicPosts syncs the posts of many topics with Options.Concurrency workers.
+// Workers share the page source, so they share its rate limit. A failing or
+// panicking topic does not stop the others; all failures are returned together,
+// in the order of topicIDs. When ctx is done no new topics are started and the
+// context error is returned once running ones have stopped.
+func (s *Scraper) SyncTopicPosts(ctx context.Context, topicIDs []int) error {
+	return s.runPool(ctx, "topic", topicIDs, s.SyncPosts)
+}
+
+// runPool calls fn for every ID with up to Options.Concurrency calls running at once;
+// kind names what the IDs refer to in errors and log lines
+func (s *Scraper) runPool(ctx context.Context, kind string, ids []int, fn func(ctx context.Context, id int) error) error {
+	workers := s.opts.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(ids) {
+		workers = len(ids)
+	}
+
+	progress := newProgressReporter(kind, len(ids), s.opts.OnProgress)
+	errs := make([]error, len(ids))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = runIsolated(ctx, kind, ids[i], fn)
+				progress.finish(errs[i] != nil)
+			}
+		}()
+	}
+
+feed:
+	for i := range ids {
+		// Checked first because select picks at random when a worker is also ready
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			break feed
+		case next <- i:
+		}
+	}
+	close(next)
+	wg.Wait()
+	progress.report(true)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// runIsolated calls fn, turning a panic into an error so one broken item
+// cannot take down the whole batch
+func runIsolated(ctx context.Context, kind string, id int, fn func(ctx context.Context, id int) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("scraper: panic while syncing %s %d: %v\n%s", kind, id, r, debug.Stack())
+			err = fmt.Errorf("panic while syncing %s %d: %v", kind, id, r)
+		}
+	}()
+	return fn(ctx, id)
+}
+
+// progressReporter aggregates the progress of a batch across workers
+type progressReporter struct {
+	mu       sync.Mutex
+	kind     string
+	progress Progress
+	started  time.Time
+	lastLog  time.Time
+	callback func(Progress)
+}
+
+func newProgressReporter(kind string, total int, callback func(Progress)) *progressReporter {
+	now := time.Now()
+	return &progressReporter{
+		kind:     kind,
+		progress: Progress{Total: total},
+		started:  now,
+		lastLog:  now,
+		callback: callback,
+	}
+}
+
+// finish records one completed item and reports the new state
+func (p *progressReporter) finish(failed bool) {
+	p.mu.Lock()
+	p.progress.Done++
+	if failed {
+		p.progress.Failed++
+	}
+	p.mu.Unlock()
+	p.report(false)
+}
+
+// report passes the current state to the callback, or logs it at most every
+// progressLogInterval; final forces a log line when there is no callback
+func (p *progressReporter) report(final bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.progress.Elapsed = time.Since(p.started)
+	if p.callback != nil {
+		if !final {
+			p.callback(p.progress)
+		}
+		return
+	}
+	if p.progress.Total == 0 || (!final && time.Since(p.lastLog) < progressLogInterval) {
+		return
+	}
+	p.lastLog = time.Now()
+	log.Printf("scraper: synced %d/%d %ss (%d failed) in %s",
+		p.progress.Done, p.progress.Total, p.kind, p.progress.Failed, p.progress.Elapsed.Round(time.Second))
+}
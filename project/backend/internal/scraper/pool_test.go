@@ -0,0 +1,104 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunPool_BoundsConcurrency(t *testing.T) {
+	var reports []Progress
+	var mu sync.Mutex
+	s := NewScraper("", nil, Options{Concurrency: 3, OnProgress: func(p Progress) {
+		mu.Lock()
+		reports = append(reports, p)
+		mu.Unlock()
+	}})
+
+	var active, maxActive atomic.Int32
+	ids := make([]int, 20)
+	for i := range ids {
+		ids[i] = i + 1
+	}
+	err := s.runPool(context.Background(), "topic", ids, func(ctx context.Context, id int) error {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			m := maxActive.Load()
+			if n <= m || maxActive.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runPool() error: %v", err)
+	}
+	if got := maxActive.Load(); got != 3 {
+		t.Errorf("max concurrent calls = %d, want 3", got)
+	}
+	if len(reports) != len(ids) {
+		t.Fatalf("got %d progress reports, want %d", len(reports), len(ids))
+	}
+	if last := reports[len(reports)-1]; last.Done != 20 || last.Total != 20 || last.Failed != 0 {
+		t.Errorf("final progress = %+v", last)
+	}
+}
+
+func TestRunPool_IsolatesFailures(t *testing.T) {
+	s := NewScraper("", nil, Options{Concurrency: 2})
+
+	var done atomic.Int32
+	err := s.runPool(context.Background(), "topic", []int{1, 2, 3, 4}, func(ctx context.Context, id int) error {
+		switch id {
+		case 2:
+			return errors.New("broken markup")
+		case 3:
+			var m map[string]int
+			m["x"] = 1 // panics
+		}
+		done.Add(1)
+		return nil
+	})
+
+	if got := done.Load(); got != 2 {
+		t.Errorf("%d topics completed, want 2", got)
+	}
+	if err == nil {
+		t.Fatal("expected the failures to be reported")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "broken markup") || !strings.Contains(msg, "panic while syncing topic 3") {
+		t.Errorf("error = %q, want both failures", msg)
+	}
+	if strings.Index(msg, "broken markup") > strings.Index(msg, "topic 3") {
+		t.Errorf("errors not in topic order: %q", msg)
+	}
+}
+
+func TestRunPool_StopsOnCancel(t *testing.T) {
+	s := NewScraper("", nil, Options{Concurrency: 2})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var started atomic.Int32
+	ids := make([]int, 100)
+	err := s.runPool(ctx, "topic", ids, func(ctx context.Context, id int) error {
+		if started.Add(1) == 2 {
+			cancel()
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("runPool() error = %v, want context.Canceled", err)
+	}
+	if got := started.Load(); got > 3 {
+		t.Errorf("%d topics started after cancellation, want the pool to stop feeding", got)
+	}
+}
@@ -0,0 +1,369 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"forum-api-wrapper/internal/content"
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/scraper/rudate"
+)
+
+// topicPostsPath returns the path of one page of a topic
+func topicPostsPath(topicID, page int) string {
+	return fmt.Sprintf("/topic.php?tid=%d&p=%d", topicID, page)
+}
+
+// postPage is a parsed page of a topic
+type postPage struct {
+	Topic    models.Topic
+	Posts    []models.Post
+	LastPage int
+}
+
+// parsePostPage extracts the topic header and all posts from a topic page.
+// Each post is a table with class "msgtable" and an id of the form "msg<postID>".
+func parsePostPage(body []byte, topicID int, fetchedAt time.Time) (*postPage, error) {
+	doc, err := parseHTML(expandBBCode(body))
+	if err != nil {
+		return nil, err
+	}
+
+	page := &postPage{
+		Topic:    models.Topic{ID: topicID},
+		LastPage: lastPageNumber(doc),
+	}
+	if title := findFirst(doc, withClass("topictitle")); title != nil {
+		page.Topic.Title = textContent(title)
+	}
+	if nav := findFirst(doc, withClass("navigation")); nav != nil {
+		if _, fid := linkWithParam(nav, "fid"); fid != 0 {
+			page.Topic.ForumID = fid
+		}
+	}
+
+	for _, msg := range findAll(doc, withClass("msgtable")) {
+		id, err := strconv.Atoi(strings.TrimPrefix(attr(msg, "id"), "msg"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: post without a numeric id", ErrUnexpectedMarkup)
+		}
+
+		post := models.Post{
+			ID:         id,
+			TopicID:    topicID,
+			TopicTitle: page.Topic.Title,
+			AuthorID:   guestUserID,
+			AuthorName: guestUsername,
+		}
+		if author := findFirst(msg, withClass("msgauthor")); author != nil {
+			if link, uid := linkWithParam(author, "uid"); link != nil {
+				post.AuthorID = uid
+				post.AuthorName = textContent(link)
+			}
+		}
+		if date := findFirst(msg, withClass("msgdate")); date != nil {
+			createdAt, err := rudate.Parse(textContent(date), fetchedAt)
+			if err != nil {
+				return nil, fmt.Errorf("post %d: %w", id, err)
+			}
+			post.CreatedAt = createdAt
+		}
+		body := findFirst(msg, withClass("msgbody"))
+		if body == nil {
+			return nil, fmt.Errorf("%w: post %d has no body", ErrUnexpectedMarkup, id)
+		}
+		post.Content = renderContent(body)
+		post.Quotes = parseQuotes(body)
+
+		page.Posts = append(page.Posts, post)
+	}
+
+	if len(page.Posts) == 0 {
+		return nil, fmt.Errorf("%w: no posts found on topic page", ErrUnexpectedMarkup)
+	}
+	return page, nil
+}
+
+// SyncPosts incrementally syncs a topic: it starts at the page holding the last
+// stored post and walks to the end, so only new posts are fetched. Topics with
+// no stored posts, or when Options.PostsPerPage is unset, are walked in full.
+func (s *Scraper) SyncPosts(ctx context.Context, topicID int) error {
+	startPage := 1
+	if s.opts.PostsPerPage > 0 {
+		states, err := s.repo.GetTopicSyncStates(ctx, []int{topicID})
+		if err != nil {
+			return fmt.Errorf("failed to load topic %d watermark: %w", topicID, err)
+		}
+		if stored := states[topicID].PostCount; stored > 0 {
+			startPage = (stored-1)/s.opts.PostsPerPage + 1
+		}
+	}
+
+	err := s.syncPostPages(ctx, topicID, startPage)
+	var fetchErr *FetchError
+	if startPage > 1 && errors.As(err, &fetchErr) && fetchErr.StatusCode == http.StatusNotFound {
+		// Posts were deleted upstream and the topic shrank below our start page
+		return s.syncPostPages(ctx, topicID, 1)
+	}
+	return err
+}
+
+// ResyncPosts walks every page of a topic from the first one
+func (s *Scraper) ResyncPosts(ctx context.Context, topicID int) error {
+	return s.syncPostPages(ctx, topicID, 1)
+}
+
+// syncPostPages walks a topic from startPage to its last page and upserts posts
+// and their authors. Pages the server reports as unchanged are skipped unless
+// some of their posts are not stored, and pages that fail to parse are
+// quarantined. A walk from the first page that parsed every page sees every
+// post of the topic, so stored posts it did not see are marked deleted.
+func (s *Scraper) syncPostPages(ctx context.Context, topicID, startPage int) error {
+	full := startPage == 1
+	var seen []int
+	var stored map[int]bool
+	// last is the last post of the highest page parsed, lastParsed that page
+	// and walked the highest page fetched
+	var last *models.Post
+	lastParsed, walked := 0, 0
+	for page, lastPage := startPage, startPage; page <= lastPage; page++ {
+		fetched, err := s.FetchPage(ctx, topicPostsPath(topicID, page))
+		if err != nil {
+			return fmt.Errorf("failed to fetch topic %d page %d: %w", topicID, page, err)
+		}
+		walked = page
+		// New posts land on later pages, so an unchanged page only tells us how
+		// many pages there are and, on a full walk, which posts it holds
+		if fetched.Unchanged && !full {
+			if lastPage, err = pageCount(fetched.Body); err != nil {
+				return fmt.Errorf("failed to parse topic %d page %d: %w", topicID, page, err)
+			}
+			// The final page still holds the last post of the topic
+			if page >= lastPage {
+				if parsed, err := parsePostPage(fetched.Body, topicID, fetched.FetchedAt); err == nil {
+					last, lastParsed = &parsed.Posts[len(parsed.Posts)-1], page
+				}
+			}
+			continue
+		}
+
+		// An unchanged page is only ingested when some of its posts are not
+		// stored, e.g. when the database was recreated but the cache kept
+		ingest := !fetched.Unchanged
+		var parsed *postPage
+		if fetched.Unchanged {
+			if parsed, err = parsePostPage(fetched.Body, topicID, fetched.FetchedAt); err != nil {
+				err = &ParseError{Page: fetched, Err: fmt.Errorf("failed to parse topic %d page %d: %w", topicID, page, err)}
+			} else {
+				if stored == nil {
+					if stored, err = s.storedPostIDs(ctx, topicID); err != nil {
+						return err
+					}
+				}
+				ingest = !allStored(parsed.Posts, stored)
+			}
+		}
+		if ingest {
+			if parsed, err = s.ingestPostPage(ctx, topicID, page, fetched); err == nil {
+				fetched.stored()
+			}
+		}
+
+		if quarantined, qErr := s.quarantineParseError(ctx, err); quarantined {
+			if qErr != nil {
+				return qErr
+			}
+			// The posts of this page are unknown, so none can be taken as deleted
+			full = false
+			if n, err := pageCount(fetched.Body); err == nil && n > lastPage {
+				lastPage = n
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		lastPage = parsed.LastPage
+		seen = appendPostIDs(seen, parsed.Posts)
+		last, lastParsed = &parsed.Posts[len(parsed.Posts)-1], page
+	}
+
+	if full {
+		deleted, err := s.repo.MarkPostsDeleted(ctx, topicID, seen)
+		if err != nil {
+			return fmt.Errorf("failed to mark deleted posts of topic %d: %w", topicID, err)
+		}
+		countRows(ctx, deleted)
+	}
+
+	// An earlier page would move the topic's last post backwards
+	if lastParsed != walked {
+		last = nil
+	}
+	return s.finishTopic(ctx, topicID, last)
+}
+
+// storedPostIDs returns the IDs of the stored posts of a topic
+func (s *Scraper) storedPostIDs(ctx context.Context, topicID int) (map[int]bool, error) {
+	posts, err := s.repo.GetAllTopicPosts(ctx, topicID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load posts of topic %d: %w", topicID, err)
+	}
+	ids := make(map[int]bool, len(posts))
+	for _, p := range posts {
+		ids[p.ID] = true
+	}
+	return ids, nil
+}
+
+// allStored reports whether every post of posts is in stored
+func allStored(posts []models.Post, stored map[int]bool) bool {
+	for _, p := range posts {
+		if !stored[p.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// appendPostIDs appends the IDs of posts to ids
+func appendPostIDs(ids []int, posts []models.Post) []int {
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// ingestPostPage parses a topic page and upserts its posts and their authors.
+// The first page also creates or refreshes the topic row, so posts can be
+// stored even if the topic was never seen in a forum listing.
+func (s *Scraper) ingestPostPage(ctx context.Context, topicID, pageNum int, page *Page) (*postPage, error) {
+	parsed, err := parsePostPage(page.Body, topicID, page.FetchedAt)
+	if err != nil {
+		return nil, &ParseError{Page: page, Err: fmt.Errorf("failed to parse topic %d page %d: %w", topicID, pageNum, err)}
+	}
+
+	if pageNum == 1 {
+		parsed.Posts[0].IsFirstPost = true
+		if err := s.storeTopicHeader(ctx, &parsed.Topic, &parsed.Posts[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.storePosts(ctx, parsed.Posts); err != nil {
+		return nil, fmt.Errorf("failed to store topic %d page %d: %w", topicID, pageNum, err)
+	}
+	for i := range parsed.Posts {
+		if err := s.storeQuotes(ctx, &parsed.Posts[i]); err != nil {
+			return nil, err
+		}
+		if err := s.storeLinks(ctx, &parsed.Posts[i]); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// storeQuotes records the posts a post quotes. Quotes that name only the author
+// are taken to quote that author's latest earlier post in the topic; those
+// matching no stored post are dropped.
+func (s *Scraper) storeQuotes(ctx context.Context, post *models.Post) error {
+	var quotedIDs []int
+	seen := make(map[int]bool)
+	for _, quote := range post.Quotes {
+		quotedID := quote.QuotedPostID
+		if quotedID == 0 {
+			var err error
+			if quotedID, err = s.repo.FindQuotedPost(ctx, post.TopicID, quote.QuotedAuthor, post.ID); err != nil {
+				return fmt.Errorf("failed to resolve quote in post %d: %w", post.ID, err)
+			}
+		}
+		if quotedID == 0 || quotedID == post.ID || seen[quotedID] {
+			continue
+		}
+		seen[quotedID] = true
+		quotedIDs = append(quotedIDs, quotedID)
+	}
+
+	if err := s.repo.ReplacePostQuotes(ctx, post.ID, quotedIDs); err != nil {
+		return fmt.Errorf("failed to store quotes of post %d: %w", post.ID, err)
+	}
+	countRows(ctx, len(quotedIDs))
+	return nil
+}
+
+// finishTopic points a topic at its last post; last is nil when the final
+// page of the topic was not parsed
+func (s *Scraper) finishTopic(ctx context.Context, topicID int, last *models.Post) error {
+	topic, err := s.repo.GetTopicByID(ctx, topicID)
+	if err != nil {
+		return fmt.Errorf("failed to load topic %d: %w", topicID, err)
+	}
+	if topic == nil || last == nil {
+		return nil
+	}
+	if topic.LastPostID != nil && *topic.LastPostID == last.ID &&
+		topic.LastPostAt != nil && topic.LastPostAt.Equal(last.CreatedAt) {
+		return nil
+	}
+	topic.LastPostID = &last.ID
+	topic.LastPostAt = &last.CreatedAt
+	if err := s.repo.UpsertTopic(ctx, topic); err != nil {
+		return fmt.Errorf("failed to store topic %d: %w", topicID, err)
+	}
+	countRows(ctx, 1)
+	return nil
+}
+
+// storeTopicHeader creates or refreshes a topic from its first page, keeping
+// counters that only the forum listing provides
+func (s *Scraper) storeTopicHeader(ctx context.Context, header *models.Topic, first *models.Post) error {
+	topic, err := s.repo.GetTopicByID(ctx, header.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load topic %d: %w", header.ID, err)
+	}
+	if topic == nil {
+		topic = &models.Topic{ID: header.ID}
+	}
+	if header.Title != "" {
+		topic.Title = header.Title
+	}
+	if header.ForumID != 0 {
+		topic.ForumID = header.ForumID
+	}
+	topic.AuthorID = first.AuthorID
+	topic.AuthorName = first.AuthorName
+	topic.CreatedAt = first.CreatedAt
+
+	return s.storeTopic(ctx, topic)
+}
+
+// storePosts derives the Markdown and plain text forms of the content of posts
+// and upserts them together with their authors, each in one batch
+func (s *Scraper) storePosts(ctx context.Context, posts []models.Post) error {
+	var authors []models.User
+	seen := make(map[int]bool)
+	for i := range posts {
+		post := &posts[i]
+		post.ContentMarkdown = content.ToMarkdown(post.Content)
+		post.ContentText = content.ToText(post.Content)
+		if !seen[post.AuthorID] {
+			seen[post.AuthorID] = true
+			authors = append(authors, models.User{ID: post.AuthorID, Username: post.AuthorName})
+		}
+	}
+
+	if err := s.repo.UpsertUsers(ctx, authors); err != nil {
+		return fmt.Errorf("failed to store post authors: %w", err)
+	}
+	if err := s.repo.UpsertPosts(ctx, posts); err != nil {
+		return fmt.Errorf("failed to store posts: %w", err)
+	}
+	countRows(ctx, len(authors)+len(posts))
+	return nil
+}
@@ -0,0 +1,97 @@
+package scraper
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePostPage(t *testing.T) {
+	page, err := parsePostPage(readFixture(t, "topic_1001_page_1.html"), 1001, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if page.Topic.ForumID != 1 || page.Topic.Title != "Как ускорить MERGE на больших таблицах?" {
+		t.Errorf("Unexpected topic header %+v", page.Topic)
+	}
+	if page.LastPage != 2 {
+		t.Errorf("Expected last page 2, got %d", page.LastPage)
+	}
+	if len(page.Posts) != 2 {
+		t.Fatalf("Expected 2 posts, got %d", len(page.Posts))
+	}
+
+	first := page.Posts[0]
+	if first.ID != 500001 || first.AuthorID != 55 || first.AuthorName != "ivanov" {
+		t.Errorf("Unexpected first post %d by %d '%s'", first.ID, first.AuthorID, first.AuthorName)
+	}
+	wantCreated := time.Date(2019, time.March, 12, 14, 45, 0, 0, time.UTC)
+	if !first.CreatedAt.Equal(wantCreated) {
+		t.Errorf("Expected created at %v, got %v", wantCreated, first.CreatedAt)
+	}
+	wantFirst := "Добрый день!<br> Есть таблица на 200 млн строк, MERGE идёт час: " +
+		"<pre><code>MERGE INTO dbo.Target AS t\nUSING dbo.Source AS s\n   ON t.Id = s.Id\n" +
+		"WHEN MATCHED THEN UPDATE SET t.Val = s.Val;</code></pre> Как <strong>ускорить</strong>?"
+	if first.Content != wantFirst {
+		t.Errorf("Unexpected first post content:\n got: %q\nwant: %q", first.Content, wantFirst)
+	}
+
+	reply := page.Posts[1].Content
+	wantReply := "<blockquote><cite>ivanov</cite>Есть таблица на 200 млн строк, MERGE идёт час</blockquote> " +
+		"Бейте на пачки:<br> " +
+		"<pre><code class=\"language-sql\">WHILE 1 = 1\nBEGIN\n    UPDATE TOP (50000) t SET Val = s.Val\n" +
+		"    FROM dbo.Target t JOIN dbo.Source s ON s.Id = t.Id AND t.Val &lt;&gt; s.Val;\n" +
+		"    IF @@ROWCOUNT = 0 BREAK;\nEND</code></pre> " +
+		"См. <a href=\"https://learn.microsoft.com/sql/t-sql/statements/merge-transact-sql\">документацию</a>."
+	if reply != wantReply {
+		t.Errorf("Unexpected reply content:\n got: %q\nwant: %q", reply, wantReply)
+	}
+}
+
+func TestParsePostPage_NestedQuotes(t *testing.T) {
+	page, err := parsePostPage(readFixture(t, "topic_1001_page_2.html"), 1001, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	post := page.Posts[0]
+	if post.AuthorID != guestUserID {
+		t.Errorf("Expected guest author, got %d", post.AuthorID)
+	}
+	want := "<blockquote><cite>aleks2</cite><blockquote><cite>ivanov</cite>MERGE идёт час</blockquote>" +
+		"Бейте на пачки</blockquote>Спасибо, помогло."
+	if post.Content != want {
+		t.Errorf("Unexpected content:\n got: %q\nwant: %q", post.Content, want)
+	}
+}
+
+func TestParsePostPage_Quotes(t *testing.T) {
+	first, err := parsePostPage(readFixture(t, "topic_1001_page_1.html"), 1001, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if quotes := first.Posts[0].Quotes; len(quotes) != 0 {
+		t.Errorf("Expected no quotes in first post, got %+v", quotes)
+	}
+	// The quote header links to the quoted post
+	if quotes := first.Posts[1].Quotes; len(quotes) != 1 || quotes[0].QuotedPostID != 500001 || quotes[0].QuotedAuthor != "ivanov" {
+		t.Errorf("Expected a quote of post 500001 by ivanov, got %+v", quotes)
+	}
+
+	second, err := parsePostPage(readFixture(t, "topic_1001_page_2.html"), 1001, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Only the outer quote counts and its header names just the author
+	if quotes := second.Posts[0].Quotes; len(quotes) != 1 || quotes[0].QuotedPostID != 0 || quotes[0].QuotedAuthor != "aleks2" {
+		t.Errorf("Expected an unresolved quote by aleks2, got %+v", quotes)
+	}
+}
+
+func TestParsePostPage_UnexpectedMarkup(t *testing.T) {
+	_, err := parsePostPage(readFixture(t, "forum_1_page_1.html"), 1001, time.Now())
+	if !errors.Is(err, ErrUnexpectedMarkup) {
+		t.Fatalf("Expected ErrUnexpectedMarkup, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/scraper/rudate"
+)
+
+// profilePath returns the path of a user's profile page
+func profilePath(userID int) string {
+	return fmt.Sprintf("/profile.php?uid=%d", userID)
+}
+
+// parseProfile extracts a user's name, rank, location, registration date, last
+// visit and post count from their profile page. Relative timestamps are resolved
+// against fetchedAt; a hidden last visit is left nil.
+func parseProfile(body []byte, userID int, fetchedAt time.Time) (*models.User, error) {
+	doc, err := parseHTML(body)
+	if err != nil {
+		return nil, err
+	}
+
+	name := findFirst(doc, withClass("username"))
+	if name == nil || findFirst(doc, withClass("profile")) == nil {
+		return nil, fmt.Errorf("%w: no profile on user page", ErrUnexpectedMarkup)
+	}
+
+	user := &models.User{ID: userID, Username: textContent(name)}
+
+	regdate := findFirst(doc, withClass("regdate"))
+	if regdate == nil {
+		return nil, fmt.Errorf("%w: no registration date on user page", ErrUnexpectedMarkup)
+	}
+	if user.RegisteredAt, err = rudate.Parse(textContent(regdate), fetchedAt); err != nil {
+		return nil, fmt.Errorf("failed to parse registration date: %w", err)
+	}
+
+	if cell := findFirst(doc, withClass("lastvisit")); cell != nil {
+		if visited, err := rudate.Parse(textContent(cell), fetchedAt); err == nil {
+			user.LastActiveAt = &visited
+		}
+	}
+	if cell := findFirst(doc, withClass("postcount")); cell != nil {
+		user.UpstreamPostCount = parseCount(firstText(cell))
+	}
+	if cell := findFirst(doc, withClass("location")); cell != nil {
+		user.Location = textContent(cell)
+	}
+	if cell := findFirst(doc, withClass("rank")); cell != nil {
+		user.Rank = textContent(cell)
+	}
+	return user, nil
+}
+
+// SyncProfile fetches a user's profile page and stores it. The user must have
+// been stored already, which happens when their posts or topics are synced.
+func (s *Scraper) SyncProfile(ctx context.Context, userID int) error {
+	page, err := s.FetchPage(ctx, profilePath(userID))
+	if err != nil {
+		return fmt.Errorf("failed to fetch profile of user %d: %w", userID, err)
+	}
+
+	if page.Unchanged {
+		user, err := s.repo.GetUserByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to load user %d: %w", userID, err)
+		}
+		if user == nil {
+			return fmt.Errorf("failed to store profile: user %d not found", userID)
+		}
+		// Nothing to parse when the stored profile came from this page; only
+		// record that it is current
+		if user.ProfileSyncedAt != nil {
+			return s.storeProfile(ctx, user)
+		}
+	}
+
+	err = s.ingestProfile(ctx, userID, page)
+	if quarantined, qErr := s.quarantineParseError(ctx, err); quarantined {
+		return qErr
+	}
+	if err != nil {
+		return err
+	}
+	page.stored()
+	return nil
+}
+
+// ingestProfile parses a profile page and stores the user's profile
+func (s *Scraper) ingestProfile(ctx context.Context, userID int, page *Page) error {
+	user, err := parseProfile(page.Body, userID, page.FetchedAt)
+	if err != nil {
+		return &ParseError{Page: page, Err: fmt.Errorf("failed to parse profile of user %d: %w", userID, err)}
+	}
+	return s.storeProfile(ctx, user)
+}
+
+// storeProfile updates a user's profile fields and marks the profile synced
+func (s *Scraper) storeProfile(ctx context.Context, user *models.User) error {
+	if err := s.repo.UpdateUserProfile(ctx, user); err != nil {
+		return fmt.Errorf("failed to store profile of user %d: %w", user.ID, err)
+	}
+	countRows(ctx, 1)
+	return nil
+}
+
+// SyncProfiles syncs the profiles of up to Options.ProfileBatch users whose
+// profile is older than Options.ProfileMaxAge, least recently synced first,
+// with Options.Concurrency workers
+func (s *Scraper) SyncProfiles(ctx context.Context) error {
+	batch := s.opts.ProfileBatch
+	if batch <= 0 {
+		batch = defaultProfileBatch
+	}
+	userIDs, err := s.repo.GetStaleProfiles(ctx, time.Now().Add(-s.opts.ProfileMaxAge), batch)
+	if err != nil {
+		return fmt.Errorf("failed to list stale profiles: %w", err)
+	}
+	return s.runPool(ctx, "user", userIDs, s.SyncProfile)
+}
@@ -0,0 +1,57 @@
+package scraper
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseProfile(t *testing.T) {
+	fetchedAt := time.Date(2024, time.May, 20, 12, 0, 0, 0, time.UTC)
+	user, err := parseProfile(readFixture(t, "profile_55.html"), 55, fetchedAt)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if user.ID != 55 || user.Username != "ivanov" {
+		t.Errorf("Expected user 55 'ivanov', got %d '%s'", user.ID, user.Username)
+	}
+	if user.Rank != "Участник" || user.Location != "Москва" {
+		t.Errorf("Unexpected rank '%s' or location '%s'", user.Rank, user.Location)
+	}
+	if user.UpstreamPostCount != 1204 {
+		t.Errorf("Expected 1204 posts, got %d", user.UpstreamPostCount)
+	}
+	wantRegistered := time.Date(2008, time.January, 15, 7, 12, 0, 0, time.UTC)
+	if !user.RegisteredAt.Equal(wantRegistered) {
+		t.Errorf("Expected registration at %v, got %v", wantRegistered, user.RegisteredAt)
+	}
+	wantVisit := time.Date(2024, time.May, 20, 11, 32, 0, 0, time.UTC)
+	if user.LastActiveAt == nil || !user.LastActiveAt.Equal(wantVisit) {
+		t.Errorf("Expected last visit at %v, got %v", wantVisit, user.LastActiveAt)
+	}
+}
+
+func TestParseProfile_HiddenFields(t *testing.T) {
+	user, err := parseProfile(readFixture(t, "profile_17.html"), 17, time.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if user.LastActiveAt != nil {
+		t.Errorf("Expected hidden last visit to be nil, got %v", user.LastActiveAt)
+	}
+	if user.Location != "" {
+		t.Errorf("Expected empty location, got '%s'", user.Location)
+	}
+	if user.UpstreamPostCount != 28412 {
+		t.Errorf("Expected 28412 posts, got %d", user.UpstreamPostCount)
+	}
+}
+
+func TestParseProfile_UnexpectedMarkup(t *testing.T) {
+	_, err := parseProfile(readFixture(t, "topic_1001_page_1.html"), 55, time.Now())
+	if !errors.Is(err, ErrUnexpectedMarkup) {
+		t.Fatalf("Expected ErrUnexpectedMarkup, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"forum-api-wrapper/internal/models"
+)
+
+// ParseError reports a fetched page whose markup its parser could not handle.
+// Syncs quarantine such pages and carry on with the rest of the forum.
+type ParseError struct {
+	Page *Page
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// quarantineParseError stores the page of a *ParseError in the parse quarantine.
+// It reports whether err was a parse error and, if so, returns the error of
+// storing it; any other error is left to the caller.
+func (s *Scraper) quarantineParseError(ctx context.Context, err error) (bool, error) {
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		return false, nil
+	}
+
+	page := parseErr.Page
+	log.Printf("scraper: quarantining %s: %v", page.URL, err)
+	quarantined := &models.QuarantinedPage{
+		URL:          page.URL,
+		Path:         page.Path,
+		BodyRef:      page.ArchiveDigest,
+		Error:        err.Error(),
+		LastFailedAt: time.Now().UTC(),
+	}
+	// Without an archived copy to refer to, the page itself is kept
+	if quarantined.BodyRef == "" {
+		quarantined.Body = string(page.Body)
+	}
+	qErr := s.repo.QuarantinePage(ctx, quarantined)
+	if qErr != nil {
+		return true, errors.Join(err, qErr)
+	}
+	countRows(ctx, 1)
+	return true, nil
+}
+
+// RetryPage fetches the page at path again and ingests it. A page that still
+// fails to parse is quarantined again and its *ParseError returned; on success
+// the caller may release it from quarantine.
+func (s *Scraper) RetryPage(ctx context.Context, path string) error {
+	ref, ok := classifyPage(path)
+	if !ok {
+		return fmt.Errorf("cannot retry %s: not a known forum page", path)
+	}
+
+	// An unchanged page is parsed from the cached copy, which is what a retry
+	// after a parser fix needs
+	page, err := s.FetchPage(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+
+	switch ref.kind {
+	case pageKindForumIndex:
+		err = s.ingestForumIndex(ctx, page)
+	case pageKindTopicList:
+		_, err = s.ingestTopicList(ctx, ref.id, ref.page, page, time.Time{})
+	case pageKindPosts:
+		var parsed *postPage
+		if parsed, err = s.ingestPostPage(ctx, ref.id, ref.page, page); err == nil && ref.page >= parsed.LastPage {
+			err = s.finishTopic(ctx, ref.id, &parsed.Posts[len(parsed.Posts)-1])
+		}
+	case pageKindProfile:
+		err = s.ingestProfile(ctx, ref.id, page)
+	}
+
+	if _, qErr := s.quarantineParseError(ctx, err); qErr != nil {
+		return qErr
+	}
+	if err != nil {
+		return err
+	}
+	page.stored()
+	return nil
+}
+
+// Subjects of forum pages, as reported by PageSubject
+const (
+	SubjectIndex = "index"
+	SubjectForum = "forum"
+	SubjectTopic = "topic"
+	SubjectUser  = "user"
+)
+
+// PageSubject tells what the forum page at path describes: the forum index, or
+// the forum, topic or user with the returned ID
+func PageSubject(path string) (string, int, bool) {
+	ref, ok := classifyPage(path)
+	if !ok {
+		return "", 0, false
+	}
+	switch ref.kind {
+	case pageKindTopicList:
+		return SubjectForum, ref.id, true
+	case pageKindPosts:
+		return SubjectTopic, ref.id, true
+	case pageKindProfile:
+		return SubjectUser, ref.id, true
+	}
+	return SubjectIndex, 0, true
+}
@@ -0,0 +1,64 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// hostLimiter spaces requests per host with a token bucket
+type hostLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// newHostLimiter creates a limiter allowing rps requests per second per host with
+// the given burst; a non-positive rps disables limiting
+func newHostLimiter(rps float64, burst int) *hostLimiter {
+	limit := rate.Inf
+	if rps > 0 {
+		limit = rate.Limit(rps)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &hostLimiter{
+		limit:    limit,
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+func (h *hostLimiter) forHost(host string) *rate.Limiter {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	limiter, ok := h.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(h.limit, h.burst)
+		h.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// SetCrawlDelay applies a robots.txt crawl delay to host. The delay can only slow
+// the host down relative to the configured budget, never speed it up.
+func (h *hostLimiter) SetCrawlDelay(host string, delay time.Duration) {
+	limit := h.limit
+	if delay > 0 {
+		if delayLimit := rate.Every(delay); delayLimit < limit {
+			limit = delayLimit
+		}
+	}
+	h.forHost(host).SetLimit(limit)
+}
+
+// Wait blocks until a request to host is allowed or ctx is done
+func (h *hostLimiter) Wait(ctx context.Context, host string) error {
+	return h.forHost(host).Wait(ctx)
+}
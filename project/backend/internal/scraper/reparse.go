@@ -0,0 +1,185 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"forum-api-wrapper/internal/archive"
+	"forum-api-wrapper/internal/models"
+)
+
+// Kinds of forum pages, in the order Reparse ingests them
+const (
+	pageKindForumIndex = iota
+	pageKindTopicList
+	pageKindPosts
+	pageKindProfile
+)
+
+// pageRef identifies a forum page by its kind and position
+type pageRef struct {
+	kind int
+	// id is the forum ID of a listing page, the topic ID of a posts page or the user ID of a profile
+	id   int
+	page int
+}
+
+// archivedPage is an archive record classified by the forum page it holds
+type archivedPage struct {
+	record archive.Record
+	pageRef
+}
+
+// Reparse rebuilds forums, topics, posts and profiles from the latest archived
+// copy of every page, without any network access. Pages are ingested parents
+// first: the forum index, then forum listings, then topic pages in order, then
+// profiles. A page that fails to parse is quarantined and does not stop the
+// others; all other failures are returned together. An archive holding pages
+// of which none is a forum page is an error, as nothing could be rebuilt.
+func (s *Scraper) Reparse(ctx context.Context, store *archive.Store) error {
+	records, err := store.Latest()
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	var pages []archivedPage
+	for _, rec := range records {
+		if rec.StatusCode != http.StatusOK {
+			continue
+		}
+		if ref, ok := classifyPage(recordPath(rec)); ok {
+			pages = append(pages, archivedPage{record: rec, pageRef: ref})
+		}
+	}
+	if len(records) > 0 && len(pages) == 0 {
+		return fmt.Errorf("none of the %d archived pages is a forum page", len(records))
+	}
+	sort.Slice(pages, func(i, j int) bool {
+		a, b := pages[i], pages[j]
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		if a.id != b.id {
+			return a.id < b.id
+		}
+		return a.page < b.page
+	})
+
+	var errs []error
+	var last *models.Post
+	for i, ap := range pages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		page, err := loadArchived(store, ap.record)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		switch ap.kind {
+		case pageKindForumIndex:
+			err = s.ingestForumIndex(ctx, page)
+		case pageKindTopicList:
+			_, err = s.ingestTopicList(ctx, ap.id, ap.page, page, time.Time{})
+		case pageKindPosts:
+			var parsed *postPage
+			if parsed, err = s.ingestPostPage(ctx, ap.id, ap.page, page); err == nil {
+				last = &parsed.Posts[len(parsed.Posts)-1]
+			}
+			// Point the topic at its last post once all of its pages are in
+			next := i + 1
+			if next == len(pages) || pages[next].kind != pageKindPosts || pages[next].id != ap.id {
+				if finishErr := s.finishTopic(ctx, ap.id, last); finishErr != nil {
+					errs = append(errs, finishErr)
+				}
+				last = nil
+			}
+		case pageKindProfile:
+			err = s.ingestProfile(ctx, ap.id, page)
+		}
+		if quarantined, qErr := s.quarantineParseError(ctx, err); quarantined {
+			err = qErr
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// classifyPage tells which forum page a path relative to the forum root refers to
+func classifyPage(path string) (pageRef, bool) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return pageRef{}, false
+	}
+	page := pageRef{page: 1}
+	if p, err := strconv.Atoi(u.Query().Get("p")); err == nil && p > 0 {
+		page.page = p
+	}
+
+	var idParam string
+	switch u.Path {
+	case forumIndexPath, "":
+		page.kind = pageKindForumIndex
+		return page, true
+	case "/forum.php":
+		page.kind, idParam = pageKindTopicList, "fid"
+	case "/topic.php":
+		page.kind, idParam = pageKindPosts, "tid"
+	case "/profile.php":
+		page.kind, idParam = pageKindProfile, "uid"
+	default:
+		return pageRef{}, false
+	}
+
+	id, err := strconv.Atoi(u.Query().Get(idParam))
+	if err != nil || id <= 0 {
+		return pageRef{}, false
+	}
+	page.id = id
+	return page, true
+}
+
+// recordPath returns the path relative to the forum root of an archived page.
+// Records archived without one fall back to the path of their URL, which is
+// only right for a forum served from the root of its host.
+func recordPath(rec archive.Record) string {
+	if rec.Path != "" {
+		return rec.Path
+	}
+	if u, err := url.Parse(rec.URL); err == nil {
+		return u.RequestURI()
+	}
+	return rec.URL
+}
+
+// loadArchived reads and decodes an archived page as FetchPage would have returned it
+func loadArchived(store *archive.Store, rec archive.Record) (*Page, error) {
+	raw, err := store.Body(rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s from archive: %w", rec.URL, err)
+	}
+	body, err := decodeBody(raw, rec.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", rec.URL, err)
+	}
+	page := &Page{
+		URL:           rec.URL,
+		StatusCode:    rec.StatusCode,
+		Header:        rec.Header,
+		Body:          body,
+		Path:          recordPath(rec),
+		FetchedAt:     rec.FetchedAt,
+		ArchiveDigest: rec.Digest,
+	}
+	return page, nil
+}
@@ -0,0 +1,126 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// FetchError describes a failed request to the forum. StatusCode is zero when
+// the request failed before a response was received.
+type FetchError struct {
+	URL        string
+	StatusCode int
+	Attempts   int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *FetchError) Error() string {
+	attempts := ""
+	if e.Attempts > 1 {
+		attempts = fmt.Sprintf(" after %d attempts", e.Attempts)
+	}
+	return fmt.Sprintf("fetch %s failed%s: %v", e.URL, attempts, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// Temporary reports whether the failure is transient, i.e. the same request may
+// succeed later: 408, 429 and 5xx gateway/availability statuses, timeouts and
+// dropped connections
+func (e *FetchError) Temporary() bool {
+	if e.StatusCode != 0 {
+		switch e.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests,
+			http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(e.Err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(e.Err, syscall.ECONNRESET) ||
+		errors.Is(e.Err, syscall.ECONNREFUSED) ||
+		errors.Is(e.Err, syscall.ECONNABORTED) ||
+		errors.Is(e.Err, io.ErrUnexpectedEOF) ||
+		errors.Is(e.Err, io.EOF)
+}
+
+// IsTransient reports whether err is a fetch failure worth retrying later
+func IsTransient(err error) bool {
+	var fetchErr *FetchError
+	return errors.As(err, &fetchErr) && fetchErr.Temporary()
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// defaultRetryBaseDelay is the first backoff when Options.RetryBaseDelay is unset
+const defaultRetryBaseDelay = 2 * time.Second
+
+// retryDelay returns how long to wait before the next attempt: exponential
+// backoff from Options.RetryBaseDelay with jitter, capped at Options.RetryMaxDelay,
+// but never shorter than the server's Retry-After. It reports false when the
+// server asks us to wait longer than the cap.
+func (h *HTTPSource) retryDelay(attempt int, retryAfter time.Duration) (time.Duration, bool) {
+	maxDelay := h.opts.RetryMaxDelay
+	if maxDelay <= 0 {
+		maxDelay = time.Minute
+	}
+	if retryAfter > maxDelay {
+		return 0, false
+	}
+
+	base := h.opts.RetryBaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	delay := base << (attempt - 1)
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+	// Equal jitter: keep half the delay, randomise the other half
+	delay = delay/2 + rand.N(delay/2+1)
+
+	if delay < retryAfter {
+		delay = retryAfter
+	}
+	return delay, true
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,177 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newRetryTestScraper returns a scraper against handler whose sleeps are recorded instead of waited
+func newRetryTestScraper(t *testing.T, handler http.HandlerFunc, opts Options) (*Scraper, *[]time.Duration) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	opts.IgnoreRobots = true
+	source := NewHTTPSource(server.URL, opts)
+	var sleeps []time.Duration
+	source.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return ctx.Err()
+	}
+	opts.Source = source
+	return NewScraper(server.URL, nil, opts), &sleeps
+}
+
+func TestFetchPage_RetriesTransientStatus(t *testing.T) {
+	requests := 0
+	s, sleeps := newRetryTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("<p>ok</p>"))
+	}, Options{MaxAttempts: 5, RetryBaseDelay: time.Second, RetryMaxDelay: time.Minute})
+
+	page, err := s.FetchPage(context.Background(), "/")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if string(page.Body) != "<p>ok</p>" {
+		t.Errorf("Unexpected body %q", page.Body)
+	}
+	if requests != 3 || len(*sleeps) != 2 {
+		t.Fatalf("Expected 3 requests and 2 sleeps, got %d and %d", requests, len(*sleeps))
+	}
+	if d := (*sleeps)[1]; d < time.Second || d > 2*time.Second {
+		t.Errorf("Expected second backoff within [1s, 2s], got %v", d)
+	}
+}
+
+func TestFetchPage_PermanentStatusNotRetried(t *testing.T) {
+	requests := 0
+	s, _ := newRetryTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	}, Options{MaxAttempts: 5, RetryBaseDelay: time.Second})
+
+	_, err := s.FetchPage(context.Background(), "/topic.php?tid=1&p=1")
+
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) {
+		t.Fatalf("Expected *FetchError, got %v", err)
+	}
+	if fetchErr.StatusCode != http.StatusNotFound || IsTransient(err) {
+		t.Errorf("Expected permanent 404, got status %d transient=%v", fetchErr.StatusCode, IsTransient(err))
+	}
+	if requests != 1 {
+		t.Errorf("Expected a single request, got %d", requests)
+	}
+}
+
+func TestFetchPage_HonoursRetryAfter(t *testing.T) {
+	requests := 0
+	s, sleeps := newRetryTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}, Options{MaxAttempts: 3, RetryBaseDelay: 10 * time.Millisecond, RetryMaxDelay: time.Minute})
+
+	if _, err := s.FetchPage(context.Background(), "/"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(*sleeps) != 1 || (*sleeps)[0] != 7*time.Second {
+		t.Errorf("Expected a single 7s wait, got %v", *sleeps)
+	}
+}
+
+func TestFetchPage_RetryAfterBeyondCap(t *testing.T) {
+	s, sleeps := newRetryTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, Options{MaxAttempts: 5, RetryBaseDelay: time.Second, RetryMaxDelay: time.Minute})
+
+	_, err := s.FetchPage(context.Background(), "/")
+	if !IsTransient(err) {
+		t.Fatalf("Expected transient error, got %v", err)
+	}
+	var fetchErr *FetchError
+	errors.As(err, &fetchErr)
+	if fetchErr.RetryAfter != time.Hour || len(*sleeps) != 0 {
+		t.Errorf("Expected to give up immediately with Retry-After 1h, got %v after %v", fetchErr.RetryAfter, *sleeps)
+	}
+}
+
+func TestFetchPage_AttemptsExhausted(t *testing.T) {
+	requests := 0
+	s, _ := newRetryTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadGateway)
+	}, Options{MaxAttempts: 3, RetryBaseDelay: time.Millisecond})
+
+	_, err := s.FetchPage(context.Background(), "/")
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) || fetchErr.Attempts != 3 || requests != 3 {
+		t.Fatalf("Expected failure after 3 attempts, got %v (%d requests)", err, requests)
+	}
+	if !IsTransient(err) {
+		t.Error("Expected exhausted 502 to remain transient")
+	}
+}
+
+func TestFetchPage_ConnectionReset(t *testing.T) {
+	requests := 0
+	s, _ := newRetryTestScraper(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.Write([]byte("ok"))
+	}, Options{MaxAttempts: 2, RetryBaseDelay: time.Millisecond})
+
+	if _, err := s.FetchPage(context.Background(), "/"); err != nil {
+		t.Fatalf("Expected dropped connection to be retried, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 requests, got %d", requests)
+	}
+}
+
+func TestRetryDelay_DefaultBase(t *testing.T) {
+	source := NewHTTPSource("http://forum.test", Options{RetryMaxDelay: time.Minute})
+	for attempt, max := range []time.Duration{2 * time.Second, 4 * time.Second} {
+		delay, ok := source.retryDelay(attempt+1, 0)
+		if !ok || delay < max/2 || delay > max {
+			t.Errorf("Expected attempt %d to back off within [%v, %v], got %v", attempt+1, max/2, max, delay)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.May, 20, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"Mon, 20 May 2024 12:00:30 GMT", 30 * time.Second},
+		{"Mon, 20 May 2024 11:00:00 GMT", 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
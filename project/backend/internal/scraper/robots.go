@@ -0,0 +1,248 @@
+package scraper
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowed is returned by HTTPSource.Fetch when robots.txt forbids fetching a path
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// robotsTTL is how long a host's robots.txt is trusted before it is fetched again
+const robotsTTL = 24 * time.Hour
+
+// robotsUnavailableTTL is how long a host whose robots.txt fails with a server
+// error is treated as disallowing everything before robots.txt is tried again
+const robotsUnavailableTTL = 30 * time.Minute
+
+// robotsRule is a single Allow or Disallow line
+type robotsRule struct {
+	allow   bool
+	length  int
+	pattern *regexp.Regexp
+}
+
+// robotsPolicy is the part of a robots.txt that applies to our user agent
+type robotsPolicy struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// allowAll is the policy used when a host has no robots.txt
+var allowAll = &robotsPolicy{}
+
+// disallowAll is the policy used while a host's robots.txt is unavailable
+var disallowAll = &robotsPolicy{rules: []robotsRule{{length: 1, pattern: regexp.MustCompile("^/")}}}
+
+// Allowed reports whether path (including its query) may be fetched. The most
+// specific (longest) matching rule wins; Allow wins ties.
+func (p *robotsPolicy) Allowed(path string) bool {
+	allowed, best := true, -1
+	for _, r := range p.rules {
+		if !r.pattern.MatchString(path) {
+			continue
+		}
+		if r.length > best || (r.length == best && r.allow) {
+			allowed, best = r.allow, r.length
+		}
+	}
+	return allowed
+}
+
+// parseRobots extracts the rules for userAgent from a robots.txt body. The group
+// naming our product token is used if present, otherwise the "*" group. Agents
+// are compared case-insensitively and may carry a version ("name/1.0"); a group
+// for a shorter name such as "forum" does not apply to "forum-api-wrapper".
+func parseRobots(body []byte, userAgent string) *robotsPolicy {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	type group struct {
+		agents []string
+		policy robotsPolicy
+	}
+	var groups []*group
+	var current *group
+	inAgents := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &group{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			inAgents = true
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil || (key == "disallow" && value == "") {
+				continue
+			}
+			current.policy.rules = append(current.policy.rules, robotsRule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: robotsPattern(value),
+			})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.policy.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	var wildcard *robotsPolicy
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			if agent == "*" {
+				if wildcard == nil {
+					wildcard = &g.policy
+				}
+			} else if token != "" && (agent == token || strings.HasPrefix(agent, token+"/")) {
+				return &g.policy
+			}
+		}
+	}
+	if wildcard != nil {
+		return wildcard
+	}
+	return allowAll
+}
+
+// robotsPattern compiles a robots.txt path pattern supporting "*" and a trailing "$"
+func robotsPattern(value string) *regexp.Regexp {
+	anchored := strings.HasSuffix(value, "$")
+	value = strings.TrimSuffix(value, "$")
+
+	parts := strings.Split(value, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// robotsCache holds the robots.txt policy of each host we crawl
+type robotsCache struct {
+	mu      sync.Mutex
+	entries map[string]robotsEntry
+}
+
+type robotsEntry struct {
+	policy    *robotsPolicy
+	expiresAt time.Time
+}
+
+// robotsPolicy returns the cached policy for the host of u, fetching robots.txt when
+// it is missing or stale. Fetching is retried like any page. A 4xx answer means the
+// host has no restrictions; a 5xx answer that outlasts the retries disallows the
+// whole host for robotsUnavailableTTL. Network errors are returned as *FetchError
+// so the page fetch fails rather than ignoring the rules.
+func (h *HTTPSource) robotsPolicy(ctx context.Context, u *url.URL) (*robotsPolicy, error) {
+	h.robots.mu.Lock()
+	entry, ok := h.robots.entries[u.Host]
+	h.robots.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.policy, nil
+	}
+
+	robotsURL := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}).String()
+	var policy *robotsPolicy
+	err := h.withRetries(ctx, func() error {
+		var err error
+		policy, err = h.fetchRobots(ctx, robotsURL, u.Host)
+		return err
+	})
+	ttl := robotsTTL
+	if err != nil {
+		var fetchErr *FetchError
+		if !errors.As(err, &fetchErr) || fetchErr.StatusCode < 500 {
+			return nil, err
+		}
+		log.Printf("scraper: %v; treating %s as disallowed for %s", err, u.Host, robotsUnavailableTTL)
+		policy, ttl = disallowAll, robotsUnavailableTTL
+	}
+
+	h.robots.mu.Lock()
+	h.robots.entries[u.Host] = robotsEntry{policy: policy, expiresAt: time.Now().Add(ttl)}
+	h.robots.mu.Unlock()
+	h.limiter.SetCrawlDelay(u.Host, policy.crawlDelay)
+
+	return policy, nil
+}
+
+// fetchRobots performs a single GET of robotsURL and parses the answer
+func (h *HTTPSource) fetchRobots(ctx context.Context, robotsURL, host string) (*robotsPolicy, error) {
+	if err := h.limiter.Wait(ctx, host); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create robots.txt request: %w", err)
+	}
+	req.Header.Set("User-Agent", h.userAgent())
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &FetchError{URL: robotsURL, Err: err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, &FetchError{URL: robotsURL, Err: fmt.Errorf("failed to read robots.txt: %w", err)}
+		}
+		return parseRobots(body, h.userAgent()), nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return allowAll, nil
+	default:
+		return nil, &FetchError{
+			URL:        robotsURL,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			Err:        fmt.Errorf("unexpected robots.txt status code: %d", resp.StatusCode),
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const testRobots = `# ReSQL robots.txt
+User-agent: *
+Disallow: /forum/search.php
+Disallow: /forum/*?print=
+Allow: /forum/
+
+User-agent: forum
+Disallow: /forum/faq.php
+
+User-agent: forum-api-wrapper
+User-agent: SomeOtherBot
+Disallow: /forum/profile.php
+Disallow: /forum/*.rss$
+Crawl-delay: 2.5
+
+User-agent: BadBot
+Disallow: /
+
+User-agent: OtherBot/2.0
+Disallow: /forum/rules.php
+`
+
+func TestParseRobots(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		path      string
+		allowed   bool
+	}{
+		{"own group disallow", DefaultUserAgent, "/forum/profile.php?uid=5", false},
+		{"own group ignores wildcard group", DefaultUserAgent, "/forum/search.php?q=x", true},
+		{"own group end anchor", DefaultUserAgent, "/forum/topics.rss", false},
+		{"own group end anchor mismatch", DefaultUserAgent, "/forum/topics.rss?x=1", true},
+		{"wildcard group disallow", "AnotherBot/2.0", "/forum/search.php?q=x", false},
+		{"wildcard group pattern", "AnotherBot/2.0", "/forum/topic.php?print=1&tid=1", false},
+		{"wildcard group allow", "AnotherBot/2.0", "/forum/topic.php?tid=1", true},
+		{"disallow all", "BadBot", "/forum/", false},
+		{"agent case", "badbot", "/forum/", false},
+		{"agent prefix of ours", DefaultUserAgent, "/forum/faq.php", true},
+		{"agent with version", "otherbot/1.1", "/forum/rules.php", false},
+		{"agent name extended", "OtherBotX", "/forum/rules.php", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := parseRobots([]byte(testRobots), tt.userAgent)
+			if got := policy.Allowed(tt.path); got != tt.allowed {
+				t.Errorf("Allowed(%q) = %v, want %v", tt.path, got, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestParseRobots_CrawlDelay(t *testing.T) {
+	policy := parseRobots([]byte(testRobots), DefaultUserAgent)
+	if policy.crawlDelay != 2500*time.Millisecond {
+		t.Errorf("Expected crawl delay 2.5s, got %v", policy.crawlDelay)
+	}
+
+	if policy := parseRobots([]byte(testRobots), "AnotherBot"); policy.crawlDelay != 0 {
+		t.Errorf("Expected no crawl delay for wildcard group, got %v", policy.crawlDelay)
+	}
+}
+
+func TestParseRobots_Empty(t *testing.T) {
+	if !parseRobots(nil, DefaultUserAgent).Allowed("/forum/topic.php?tid=1") {
+		t.Error("Expected empty robots.txt to allow everything")
+	}
+}
+
+// newRobotsTestSource returns a source against handler that honours robots.txt
+// and does not wait between retries
+func newRobotsTestSource(t *testing.T, handler http.HandlerFunc) *HTTPSource {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	source := NewHTTPSource(server.URL, Options{MaxAttempts: 3})
+	source.sleep = func(ctx context.Context, d time.Duration) error { return ctx.Err() }
+	return source
+}
+
+func TestFetch_RetriesRobots(t *testing.T) {
+	robotsRequests := 0
+	source := newRobotsTestSource(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsRequests++
+			if robotsRequests == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("User-agent: *\nDisallow: /profile.php\n"))
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	if _, err := source.Fetch(context.Background(), "/topic.php?tid=1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := source.Fetch(context.Background(), "/profile.php?uid=1"); !errors.Is(err, ErrDisallowed) {
+		t.Errorf("Expected ErrDisallowed, got %v", err)
+	}
+	if robotsRequests != 2 {
+		t.Errorf("Expected robots.txt requested twice, got %d", robotsRequests)
+	}
+}
+
+func TestFetch_RobotsUnavailable(t *testing.T) {
+	robotsRequests, pageRequests := 0, 0
+	source := newRobotsTestSource(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsRequests++
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		pageRequests++
+		w.Write([]byte("ok"))
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := source.Fetch(context.Background(), "/topic.php?tid=1"); !errors.Is(err, ErrDisallowed) {
+			t.Errorf("Expected ErrDisallowed while robots.txt fails, got %v", err)
+		}
+	}
+	// The failure is remembered for a while rather than retried for every page
+	if robotsRequests != 3 || pageRequests != 0 {
+		t.Errorf("Expected 3 robots.txt requests and no page requests, got %d and %d", robotsRequests, pageRequests)
+	}
+
+	// Once it expires, robots.txt is tried again
+	host, _ := url.Parse(source.baseURL)
+	source.robots.entries[host.Host] = robotsEntry{}
+	if _, err := source.Fetch(context.Background(), "/topic.php?tid=1"); !errors.Is(err, ErrDisallowed) || robotsRequests != 6 {
+		t.Errorf("Expected robots.txt fetched again after expiry, got %v after %d requests", err, robotsRequests)
+	}
+}
+
+func TestHostLimiter(t *testing.T) {
+	limiter := newHostLimiter(50, 1)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.Wait(ctx, "resql.ru"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("Expected 3 requests at 50 rps to take at least 40ms, took %v", elapsed)
+	}
+
+	// Other hosts have their own budget
+	limiter.SetCrawlDelay("slow.example", time.Hour)
+	if got := limiter.forHost("resql.ru").Limit(); got != 50 {
+		t.Errorf("Expected resql.ru limit to stay at 50, got %v", got)
+	}
+
+	// A crawl delay never speeds a host up
+	limiter.SetCrawlDelay("resql.ru", time.Millisecond)
+	if got := limiter.forHost("resql.ru").Limit(); got != 50 {
+		t.Errorf("Expected short crawl delay to keep limit at 50, got %v", got)
+	}
+}
@@ -0,0 +1,120 @@
+package rudate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	// The forum's time zone must resolve even where the system has no tz database
+	_ "time/tzdata"
+)
+
+// Location is the time zone forum pages display timestamps in. Moscow time has
+// not always been UTC+3, so historical offsets come from the tz database.
+var Location = mustLoadLocation("Europe/Moscow")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(fmt.Sprintf("rudate: %v", err))
+	}
+	return loc
+}
+
+var (
+	relativeTimestamp = regexp.MustCompile(`^(сегодня|вчера|позавчера),?\s*(?:в\s+)?(\d{1,2}):(\d{2})$`)
+	absoluteTimestamp = regexp.MustCompile(`^(\d{1,2})\s+([а-я]+)\.?\s+(\d{2}|\d{4})(?:\s*г\.?)?(?:,?\s*(?:в\s+)?(\d{1,2}):(\d{2}))?$`)
+)
+
+// relativeDays are how many days before the fetch each relative day word means
+var relativeDays = map[string]int{"сегодня": 0, "вчера": 1, "позавчера": 2}
+
+// months maps the accepted spellings of each month, abbreviated and in full, in
+// the nominative ("март") and genitive ("марта") cases
+var months = map[string]time.Month{}
+
+func init() {
+	forms := map[time.Month][]string{
+		time.January:   {"янв", "январь", "января"},
+		time.February:  {"фев", "февр", "февраль", "февраля"},
+		time.March:     {"мар", "март", "марта"},
+		time.April:     {"апр", "апрель", "апреля"},
+		time.May:       {"май", "мая"},
+		time.June:      {"июн", "июнь", "июня"},
+		time.July:      {"июл", "июль", "июля"},
+		time.August:    {"авг", "август", "августа"},
+		time.September: {"сен", "сент", "сентябрь", "сентября"},
+		time.October:   {"окт", "октябрь", "октября"},
+		time.November:  {"ноя", "нояб", "ноябрь", "ноября"},
+		time.December:  {"дек", "декабрь", "декабря"},
+	}
+	for month, names := range forms {
+		for _, name := range names {
+			months[name] = month
+		}
+	}
+}
+
+// Parse parses a forum timestamp in Moscow time and returns it in UTC. It
+// accepts relative days ("сегодня, 14:32", "вчера, 09:10"), which are anchored
+// to fetchedAt, and dates with a month name in either letter case or grammatical
+// case ("12 мар 19, 17:45", "12 Марта 2019, 17:45"). A date without a time is
+// taken as midnight. Two-digit years are placed in the century that keeps them
+// from lying more than a year after fetchedAt.
+func Parse(s string, fetchedAt time.Time) (time.Time, error) {
+	norm := strings.ToLower(strings.Join(strings.Fields(s), " "))
+	local := fetchedAt.In(Location)
+
+	if m := relativeTimestamp.FindStringSubmatch(norm); m != nil {
+		hour, minute, err := clock(m[2], m[3])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w in timestamp %q", err, s)
+		}
+		day := local.Day() - relativeDays[m[1]]
+		return time.Date(local.Year(), local.Month(), day, hour, minute, 0, 0, Location).UTC(), nil
+	}
+
+	if m := absoluteTimestamp.FindStringSubmatch(norm); m != nil {
+		month, ok := months[m[2]]
+		if !ok {
+			return time.Time{}, fmt.Errorf("unknown month %q in timestamp %q", m[2], s)
+		}
+		year, _ := strconv.Atoi(m[3])
+		if len(m[3]) == 2 {
+			year += 2000
+			if year > local.Year()+1 {
+				year -= 100
+			}
+		}
+		day, _ := strconv.Atoi(m[1])
+		if day < 1 || day > daysIn(year, month) {
+			return time.Time{}, fmt.Errorf("day %d out of range in timestamp %q", day, s)
+		}
+		hour, minute := 0, 0
+		if m[4] != "" {
+			var err error
+			if hour, minute, err = clock(m[4], m[5]); err != nil {
+				return time.Time{}, fmt.Errorf("%w in timestamp %q", err, s)
+			}
+		}
+		return time.Date(year, month, day, hour, minute, 0, 0, Location).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized timestamp %q", s)
+}
+
+// clock parses and validates the hour and minute of a timestamp
+func clock(h, m string) (int, int, error) {
+	hour, _ := strconv.Atoi(h)
+	minute, _ := strconv.Atoi(m)
+	if hour > 23 || minute > 59 {
+		return 0, 0, fmt.Errorf("time %s:%s out of range", h, m)
+	}
+	return hour, minute, nil
+}
+
+// daysIn returns the number of days in a month
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
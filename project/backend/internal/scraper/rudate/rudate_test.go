@@ -0,0 +1,83 @@
+package rudate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// 2019-03-14 08:00 in Moscow
+	fetchedAt := time.Date(2019, time.March, 14, 5, 0, 0, 0, time.UTC)
+	// 00:30 on 2019-03-14 in Moscow is still the 13th in UTC
+	afterMidnight := time.Date(2019, time.March, 13, 21, 30, 0, 0, time.UTC)
+
+	utc := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		in        string
+		fetchedAt time.Time
+		want      time.Time
+	}{
+		{"today", "сегодня, 14:32", fetchedAt, utc(2019, time.March, 14, 11, 32)},
+		{"yesterday", "вчера, 09:10", fetchedAt, utc(2019, time.March, 13, 6, 10)},
+		{"day before yesterday", "позавчера, 23:59", fetchedAt, utc(2019, time.March, 12, 20, 59)},
+		{"today anchored to Moscow date", "сегодня, 00:10", afterMidnight, utc(2019, time.March, 13, 21, 10)},
+		{"yesterday across month", "вчера, 12:00", utc(2019, time.March, 1, 9, 0), utc(2019, time.February, 28, 9, 0)},
+		{"capitalized relative day", "Вчера, 09:10", fetchedAt, utc(2019, time.March, 13, 6, 10)},
+		{"relative day with в", "сегодня в 14:32", fetchedAt, utc(2019, time.March, 14, 11, 32)},
+		{"abbreviated month", "12 мар 19, 17:45", fetchedAt, utc(2019, time.March, 12, 14, 45)},
+		{"capitalized month", "12 Мар 19, 17:45", fetchedAt, utc(2019, time.March, 12, 14, 45)},
+		{"upper case month", "12 МАР 19, 17:45", fetchedAt, utc(2019, time.March, 12, 14, 45)},
+		{"abbreviation with dot", "01 сент. 19, 08:00", fetchedAt, utc(2019, time.September, 1, 5, 0)},
+		{"genitive month", "12 марта 2019, 17:45", fetchedAt, utc(2019, time.March, 12, 14, 45)},
+		{"nominative month", "1 май 2018, 10:00", fetchedAt, utc(2018, time.May, 1, 7, 0)},
+		{"genitive may", "9 мая 18, 10:00", fetchedAt, utc(2018, time.May, 9, 7, 0)},
+		{"year suffix", "3 февраля 2005 г., 12:00", fetchedAt, utc(2005, time.February, 3, 9, 0)},
+		{"date without time", "15 янв 08", fetchedAt, utc(2008, time.January, 14, 21, 0)},
+		{"extra whitespace", "  12 мар  19,   17:45 ", fetchedAt, utc(2019, time.March, 12, 14, 45)},
+		{"last century", "03 дек 99, 21:40", fetchedAt, utc(1999, time.December, 3, 18, 40)},
+		{"new year in Moscow", "01 янв 19, 00:05", fetchedAt, utc(2018, time.December, 31, 21, 5)},
+		// Moscow kept summer time until 2011 and stayed on UTC+4 until 2014
+		{"summer time", "03 сен 04, 21:40", fetchedAt, utc(2004, time.September, 3, 17, 40)},
+		{"permanent UTC+4", "15 янв 12, 10:00", fetchedAt, utc(2012, time.January, 15, 6, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.in, tt.fetchedAt)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %v", tt.in, err)
+			}
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{"empty", ""},
+		{"hidden", "Скрыт"},
+		{"unknown month", "12 foo 19, 17:45"},
+		{"unknown relative day", "завтра, 10:00"},
+		{"hour out of range", "сегодня, 24:00"},
+		{"minute out of range", "12 мар 19, 17:60"},
+		{"day out of range", "30 фев 19, 10:00"},
+		{"zero day", "0 мар 19, 10:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Parse(tt.in, time.Now()); err == nil {
+				t.Errorf("Parse(%q) = %v, want error", tt.in, got)
+			}
+		})
+	}
+}
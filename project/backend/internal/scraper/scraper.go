@@ -2,74 +2,182 @@ package scraper
 
 import (
 	"context"
-	"fmt"
-	"io"
+	"errors"
+	"log"
 	"net/http"
 	"time"
+
+	"forum-api-wrapper/internal/archive"
+	"forum-api-wrapper/internal/repository"
 )
 
-// Scraper handles scraping forum data
-type Scraper struct {
-	baseURL    string
-	httpClient *http.Client
+// ErrUnexpectedMarkup is returned by parsers when a page does not have the expected structure
+var ErrUnexpectedMarkup = errors.New("unexpected page markup")
+
+// defaultProfileBatch is used when Options.ProfileBatch is not set
+const defaultProfileBatch = 500
+
+// DefaultUserAgent identifies the scraper to the forum when Options.UserAgent is empty
+const DefaultUserAgent = "forum-api-wrapper/1.0 (+https://github.com/nutritiouss/ai-dev-tools-datatalks)"
+
+// Options configures how the scraper crawls the forum
+type Options struct {
+	// MaxTopicPages limits how many listing pages SyncTopics walks per forum (0 = no limit)
+	MaxTopicPages int
+	// TopicMaxAge stops SyncTopics at topics whose last post is older than this (0 = no cutoff)
+	TopicMaxAge time.Duration
+	// PostsPerPage is the forum's page size, used by SyncPosts to resume at the
+	// page holding the last stored post (0 = always walk topics in full)
+	PostsPerPage int
+	// Concurrency is how many topics SyncTopicPosts syncs at once (0 or 1 = one at a time)
+	Concurrency int
+	// OnProgress is called as topics or profiles of a batch complete (nil = log progress periodically)
+	OnProgress func(Progress)
+
+	// ProfileMaxAge is how long a scraped profile stays current before SyncProfiles
+	// fetches it again (0 = every profile is stale)
+	ProfileMaxAge time.Duration
+	// ProfileBatch caps how many profiles one SyncProfiles call fetches (default 500)
+	ProfileBatch int
+
+	// UserAgent is sent with every request (default DefaultUserAgent)
+	UserAgent string
+	// RequestsPerSecond is the request budget per host (0 = unlimited)
+	RequestsPerSecond float64
+	// Burst is how many requests may be sent back to back within the budget
+	Burst int
+	// IgnoreRobots disables robots.txt checks; only meant for fixtures and local mirrors
+	IgnoreRobots bool
+
+	// MaxAttempts caps how often a page is requested when failures are transient (0 or 1 = no retries)
+	MaxAttempts int
+	// RetryBaseDelay is the backoff before the first retry; it doubles with each attempt (default 2s)
+	RetryBaseDelay time.Duration
+	// RetryMaxDelay caps the backoff and the Retry-After we are willing to wait (default 1m)
+	RetryMaxDelay time.Duration
+
+	// Cache enables conditional requests with stored ETag/Last-Modified validators (nil = disabled)
+	Cache PageCache
+	// Archive keeps every fetched page so the database can be rebuilt with Reparse (nil = disabled)
+	Archive *archive.Store
+
+	// Source retrieves pages (nil = fetch over HTTP from the base URL with the options above)
+	Source PageSource
+
+	// ForumHosts are the hosts whose topic links in posts are resolved to topics,
+	// besides the host of the base URL (default DefaultForumHosts)
+	ForumHosts []string
 }
 
-// NewScraper creates a new scraper instance
-func NewScraper(baseURL string) *Scraper {
-	return &Scraper{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// Page is a fetched forum page
+type Page struct {
+	URL string
+	// Path is the page's path relative to the forum root, as passed to FetchPage
+	Path       string
+	StatusCode int
+	Header     http.Header
+	// Body is the page decoded to UTF-8
+	Body      []byte
+	FetchedAt time.Time
+	// Unchanged is set when the server answered 304 Not Modified; Body then holds the cached copy
+	Unchanged bool
+	// ArchiveDigest refers to the raw page in Options.Archive (empty when not archived)
+	ArchiveDigest string
+	// commit is Response.commit of the fetch
+	commit func()
+}
+
+// stored records that the page has been parsed and stored, so the source may
+// report it as unchanged from now on
+func (p *Page) stored() {
+	if p.commit != nil {
+		p.commit()
 	}
 }
 
-// FetchPage fetches a page from the forum
-func (s *Scraper) FetchPage(ctx context.Context, path string) ([]byte, error) {
-	url := s.baseURL + path
-	
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// DefaultOptions returns options suitable for crawling the live forum
+func DefaultOptions() Options {
+	return Options{
+		PostsPerPage:      25,
+		Concurrency:       4,
+		ProfileMaxAge:     7 * 24 * time.Hour,
+		UserAgent:         DefaultUserAgent,
+		RequestsPerSecond: 1,
+		Burst:             2,
+		MaxAttempts:       5,
+		RetryBaseDelay:    2 * time.Second,
+		RetryMaxDelay:     2 * time.Minute,
 	}
+}
 
-	// Set user agent to avoid blocking
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+// Scraper handles scraping forum data
+type Scraper struct {
+	source PageSource
+	repo   repository.Repository
+	opts   Options
+	// forumHosts are the hosts of links that point at the forum
+	forumHosts map[string]bool
+}
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch page: %w", err)
+// NewScraper creates a new scraper instance that stores scraped data through repo.
+// Pages are fetched from baseURL unless Options.Source is set.
+func NewScraper(baseURL string, repo repository.Repository, opts Options) *Scraper {
+	source := opts.Source
+	if source == nil {
+		source = NewHTTPSource(baseURL, opts)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	return &Scraper{
+		source:     source,
+		repo:       repo,
+		opts:       opts,
+		forumHosts: forumHostSet(baseURL, opts.ForumHosts),
 	}
+}
 
-	body, err := io.ReadAll(resp.Body)
+// FetchPage retrieves a page from the page source with its body decoded to UTF-8.
+// Pages the source reports as not modified come back with Page.Unchanged set.
+func (s *Scraper) FetchPage(ctx context.Context, path string) (*Page, error) {
+	resp, err := s.source.Fetch(ctx, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
 	}
+	countPage(ctx)
 
-	return body, nil
-}
-
-// SyncForums syncs forum data from the source
-// This is a placeholder - actual implementation would parse HTML and extract forum data
-func (s *Scraper) SyncForums(ctx context.Context) error {
-	// TODO: Implement actual scraping logic
-	// For now, this is a placeholder
-	return nil
-}
-
-// SyncTopics syncs topic data from a forum
-func (s *Scraper) SyncTopics(ctx context.Context, forumID int) error {
-	// TODO: Implement actual scraping logic
-	return nil
+	page := &Page{
+		URL:        resp.URL,
+		Path:       path,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		FetchedAt:  resp.FetchedAt,
+		Unchanged:  resp.Unchanged,
+		commit:     resp.commit,
+	}
+	if page.Body, err = decodeBody(resp.Body, resp.Header.Get("Content-Type")); err != nil {
+		return nil, err
+	}
+	if !resp.Unchanged {
+		page.ArchiveDigest = s.archivePage(page, resp.Body)
+	}
+	return page, nil
 }
 
-// SyncPosts syncs post data from a topic
-func (s *Scraper) SyncPosts(ctx context.Context, topicID int) error {
-	// TODO: Implement actual scraping logic
-	return nil
+// archivePage stores the raw body of a freshly fetched page in the archive, if
+// enabled, and returns its digest. Like the cache, the archive is best effort:
+// write failures are logged and leave the digest empty.
+func (s *Scraper) archivePage(page *Page, raw []byte) string {
+	if s.opts.Archive == nil {
+		return ""
+	}
+	rec := &archive.Record{
+		URL:        page.URL,
+		Path:       page.Path,
+		FetchedAt:  page.FetchedAt,
+		StatusCode: page.StatusCode,
+		Header:     page.Header,
+	}
+	if err := s.opts.Archive.Put(rec, raw); err != nil {
+		log.Printf("scraper: archiving %s failed: %v", page.URL, err)
+		return ""
+	}
+	return rec.Digest
 }
@@ -0,0 +1,96 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PageSource retrieves forum pages by path relative to the forum root
+type PageSource interface {
+	// Fetch returns the page at path. A missing page is a *FetchError with
+	// StatusCode 404, so callers can tell it apart from other failures.
+	Fetch(ctx context.Context, path string) (*Response, error)
+}
+
+// Response is a page as returned by a PageSource, before charset decoding
+type Response struct {
+	URL        string
+	StatusCode int
+	Header     http.Header
+	// Body is the raw page in whatever encoding the forum served it
+	Body      []byte
+	FetchedAt time.Time
+	// Unchanged is set when the page has not changed since it was last fetched
+	Unchanged bool
+	// commit, when set, makes the source answer later fetches of the page with
+	// Unchanged. It is called once the page has been stored, so a page that
+	// failed to parse or store is fetched in full again.
+	commit func()
+}
+
+// DirSource serves pages saved in a directory, for offline runs, parser
+// development and tests. A page is stored in the file named by DirSource.File:
+// the forum index is index.html and every other page is its path and query
+// escaped into a single file name, e.g. "forum.php%3Ffid=1&p=2.html".
+// A page's modification time is taken as its fetch time, which anchors
+// relative dates such as "сегодня".
+type DirSource struct {
+	dir string
+}
+
+// NewDirSource creates a page source reading from dir
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{dir: dir}
+}
+
+// File returns the name of the file holding the page at path
+func (d *DirSource) File(path string) string {
+	path = strings.TrimPrefix(path, "/")
+	if path == "" {
+		return "index.html"
+	}
+	return url.PathEscape(path) + ".html"
+}
+
+// Fetch reads the page at path from the directory
+func (d *DirSource) Fetch(ctx context.Context, path string) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	name := filepath.Join(d.dir, d.File(path))
+	pageURL := "file://" + filepath.ToSlash(name)
+	info, err := os.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, &FetchError{
+			URL:        pageURL,
+			StatusCode: http.StatusNotFound,
+			Attempts:   1,
+			Err:        fmt.Errorf("no saved page for %s", path),
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat saved page: %w", err)
+	}
+
+	body, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved page: %w", err)
+	}
+
+	return &Response{
+		URL:        pageURL,
+		StatusCode: http.StatusOK,
+		// The charset is left to the page itself, as on the live forum
+		Header:    http.Header{"Content-Type": {"text/html"}},
+		Body:      body,
+		FetchedAt: info.ModTime(),
+	}, nil
+}
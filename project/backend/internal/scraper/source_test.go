@@ -0,0 +1,57 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirSource_File(t *testing.T) {
+	d := NewDirSource(t.TempDir())
+	tests := map[string]string{
+		"/":                       "index.html",
+		"/forum.php?fid=1&p=2":    "forum.php%3Ffid=1&p=2.html",
+		"/topic.php?tid=1001&p=1": "topic.php%3Ftid=1001&p=1.html",
+	}
+	for path, want := range tests {
+		if got := d.File(path); got != want {
+			t.Errorf("File(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestDirSource_Fetch(t *testing.T) {
+	dir := t.TempDir()
+	d := NewDirSource(dir)
+
+	savedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	name := filepath.Join(dir, d.File("/forum.php?fid=1&p=1"))
+	if err := os.WriteFile(name, readFixture(t, "forum_1_page_1.html"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, savedAt, savedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewScraper("", nil, Options{Source: d})
+	page, err := s.FetchPage(context.Background(), "/forum.php?fid=1&p=1")
+	if err != nil {
+		t.Fatalf("FetchPage() error: %v", err)
+	}
+	if !page.FetchedAt.Equal(savedAt) {
+		t.Errorf("FetchedAt = %v, want the file's modification time %v", page.FetchedAt, savedAt)
+	}
+	if _, err := parseTopicList(page.Body, 1, page.FetchedAt); err != nil {
+		t.Errorf("saved page does not parse: %v", err)
+	}
+
+	_, err = s.FetchPage(context.Background(), "/forum.php?fid=1&p=2")
+	var fetchErr *FetchError
+	if !errors.As(err, &fetchErr) || fetchErr.StatusCode != http.StatusNotFound {
+		t.Errorf("missing page error = %v, want a 404 *FetchError", err)
+	}
+}
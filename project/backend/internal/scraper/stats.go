@@ -0,0 +1,43 @@
+package scraper
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Stats counts the work done by scraper calls made with a context from WithStats
+type Stats struct {
+	pages atomic.Int64
+	rows  atomic.Int64
+}
+
+// PagesFetched returns how many pages were fetched, including ones answered with 304
+func (st *Stats) PagesFetched() int {
+	return int(st.pages.Load())
+}
+
+// RowsUpserted returns how many rows were written to the repository
+func (st *Stats) RowsUpserted() int {
+	return int(st.rows.Load())
+}
+
+type statsKey struct{}
+
+// WithStats returns a context that makes the scraper count its work in st
+func WithStats(ctx context.Context, st *Stats) context.Context {
+	return context.WithValue(ctx, statsKey{}, st)
+}
+
+// countPage records a fetched page on the stats attached to ctx, if any
+func countPage(ctx context.Context) {
+	if st, ok := ctx.Value(statsKey{}).(*Stats); ok {
+		st.pages.Add(1)
+	}
+}
+
+// countRows records n upserted rows on the stats attached to ctx, if any
+func countRows(ctx context.Context, n int) {
+	if st, ok := ctx.Value(statsKey{}).(*Stats); ok {
+		st.rows.Add(int64(n))
+	}
+}
@@ -0,0 +1,92 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// SyncForum brings one forum up to date: it walks the topic listing and then
+// syncs posts of every topic with new activity through SyncTopicPosts.
+func (s *Scraper) SyncForum(ctx context.Context, forumID int) error {
+	changed, err := s.SyncTopics(ctx, forumID)
+	if err != nil {
+		return err
+	}
+	return s.SyncTopicPosts(ctx, changed)
+}
+
+// Sync runs an incremental sync cycle: the forum index, then every stored forum
+func (s *Scraper) Sync(ctx context.Context) error {
+	if err := s.SyncForums(ctx); err != nil {
+		return err
+	}
+
+	forumIDs, err := s.forumIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, forumID := range forumIDs {
+		if err := s.SyncForum(ctx, forumID); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("forum %d: %w", forumID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FullSync walks the whole forum: the index, then every listing page of every
+// stored forum and every page of every topic listed, through ResyncPosts.
+// Unlike Sync it does not stop at Options.MaxTopicPages, Options.TopicMaxAge or
+// unchanged pages, nor start topics at their last stored page, so it catches
+// what incremental syncs miss: topics that changed while off the walked pages,
+// and posts edited or deleted on earlier pages.
+func (s *Scraper) FullSync(ctx context.Context) error {
+	if err := s.SyncForums(ctx); err != nil {
+		return err
+	}
+
+	forumIDs, err := s.forumIDs(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, forumID := range forumIDs {
+		// Topics listed before a listing page failed are still walked
+		_, listed, err := s.walkTopics(ctx, forumID, true)
+		if postsErr := s.runPool(ctx, "topic", listed, s.ResyncPosts); postsErr != nil {
+			err = errors.Join(err, postsErr)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("forum %d: %w", forumID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// forumIDs lists the IDs of all stored forums
+func (s *Scraper) forumIDs(ctx context.Context) ([]int, error) {
+	const pageSize = 100
+
+	var ids []int
+	for page := 1; ; page++ {
+		forums, total, err := s.repo.GetForums(ctx, page, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list forums: %w", err)
+		}
+		for _, f := range forums {
+			ids = append(ids, f.ID)
+		}
+		if len(forums) == 0 || len(ids) >= total {
+			return ids, nil
+		}
+	}
+}
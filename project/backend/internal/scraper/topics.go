@@ -0,0 +1,291 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scraper/rudate"
+)
+
+// Guests post without a profile; their content is attributed to a shared placeholder user.
+// Users are keyed by ID only, so a member may also be called "guest".
+const (
+	guestUserID   = 0
+	guestUsername = "guest"
+)
+
+// topicListPath returns the path of one listing page of a forum
+func topicListPath(forumID, page int) string {
+	return fmt.Sprintf("/forum.php?fid=%d&p=%d", forumID, page)
+}
+
+// topicEntry is a topic row parsed from a forum listing page
+type topicEntry struct {
+	Topic  models.Topic
+	Sticky bool
+}
+
+// topicListPage is a parsed forum listing page
+type topicListPage struct {
+	Topics   []topicEntry
+	LastPage int
+}
+
+// parseTopicList extracts topic rows and the pager from a forum listing page.
+// Relative timestamps ("сегодня", "вчера") are resolved against fetchedAt.
+func parseTopicList(body []byte, forumID int, fetchedAt time.Time) (*topicListPage, error) {
+	doc, err := parseHTML(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if findFirst(doc, withClass("topictable")) == nil {
+		return nil, fmt.Errorf("%w: no topic table on forum page", ErrUnexpectedMarkup)
+	}
+
+	page := &topicListPage{LastPage: lastPageNumber(doc)}
+	for _, row := range findAll(doc, withClass("topicrow")) {
+		cell := findFirst(row, withClass("topictitle"))
+		if cell == nil {
+			continue
+		}
+		link, id := linkWithParam(cell, "tid")
+		if link == nil {
+			continue
+		}
+
+		entry := topicEntry{
+			Topic: models.Topic{
+				ID:         id,
+				Title:      textContent(link),
+				ForumID:    forumID,
+				AuthorID:   guestUserID,
+				AuthorName: guestUsername,
+			},
+			Sticky: hasClass(row, "sticky"),
+		}
+
+		if author := findFirst(row, withClass("author")); author != nil {
+			if link, uid := linkWithParam(author, "uid"); link != nil {
+				entry.Topic.AuthorID = uid
+				entry.Topic.AuthorName = textContent(link)
+			}
+		}
+		if replies := findFirst(row, withClass("replies")); replies != nil {
+			entry.Topic.UpstreamReplyCount = parseCount(textContent(replies))
+		}
+		if views := findFirst(row, withClass("views")); views != nil {
+			entry.Topic.ViewCount = parseCount(textContent(views))
+		}
+		if lastPost := findFirst(row, withClass("lastpost")); lastPost != nil {
+			at, err := rudate.Parse(firstText(lastPost), fetchedAt)
+			if err != nil {
+				return nil, fmt.Errorf("topic %d: %w", id, err)
+			}
+			entry.Topic.LastPostAt = &at
+		}
+
+		page.Topics = append(page.Topics, entry)
+	}
+
+	return page, nil
+}
+
+// SyncTopics walks the listing pages of a forum and upserts each topic and its author.
+// It returns the IDs of topics with activity not yet mirrored: topics whose upstream
+// last post is newer than the stored one, or that have fewer stored posts than the
+// listing reports.
+// The walk stops after Options.MaxTopicPages pages, at the first page that reaches
+// topics whose last post is older than Options.TopicMaxAge (sticky topics excepted),
+// or at the first page the server reports as unchanged whose topics are all stored.
+func (s *Scraper) SyncTopics(ctx context.Context, forumID int) ([]int, error) {
+	changed, _, err := s.walkTopics(ctx, forumID, false)
+	return changed, err
+}
+
+// walkTopics walks the listing pages of a forum like SyncTopics and also returns
+// the IDs of every topic it saw listed. A full walk ignores Options.MaxTopicPages
+// and Options.TopicMaxAge and goes on past unchanged pages to the last one.
+func (s *Scraper) walkTopics(ctx context.Context, forumID int, full bool) (changed, listed []int, err error) {
+	var cutoff time.Time
+	if s.opts.TopicMaxAge > 0 && !full {
+		cutoff = time.Now().Add(-s.opts.TopicMaxAge)
+	}
+	maxPages := s.opts.MaxTopicPages
+	if full {
+		maxPages = 0
+	}
+
+	for page := 1; maxPages <= 0 || page <= maxPages; page++ {
+		fetched, err := s.FetchPage(ctx, topicListPath(forumID, page))
+		if err != nil {
+			return changed, listed, fmt.Errorf("failed to fetch forum %d page %d: %w", forumID, page, err)
+		}
+		// Activity moves topics to the top of the listing, so once a page is
+		// unchanged the pages after it are too
+		if fetched.Unchanged {
+			listing, stored, err := s.topicsStored(ctx, forumID, fetched, cutoff)
+			if err != nil {
+				return changed, listed, err
+			}
+			if stored && !full {
+				break
+			}
+			if stored {
+				listed = appendTopicIDs(listed, listing.Topics)
+				if page >= listing.LastPage {
+					break
+				}
+				continue
+			}
+		}
+
+		result, err := s.ingestTopicList(ctx, forumID, page, fetched, cutoff)
+		if quarantined, qErr := s.quarantineParseError(ctx, err); quarantined {
+			if qErr != nil {
+				return changed, listed, qErr
+			}
+			// The pager may still be readable and tell whether there is more to walk
+			if lastPage, err := pageCount(fetched.Body); err != nil || page >= lastPage {
+				break
+			}
+			continue
+		}
+		if err != nil {
+			return changed, listed, err
+		}
+		fetched.stored()
+		changed = append(changed, result.Changed...)
+		listed = append(listed, result.Stored...)
+
+		if result.ReachedCutoff || page >= result.LastPage {
+			break
+		}
+	}
+
+	return changed, listed, nil
+}
+
+// appendTopicIDs appends the IDs of the topics of entries to ids
+func appendTopicIDs(ids []int, entries []topicEntry) []int {
+	for _, entry := range entries {
+		ids = append(ids, entry.Topic.ID)
+	}
+	return ids
+}
+
+// topicListResult is what ingesting one forum listing page found
+type topicListResult struct {
+	LastPage      int
+	Changed       []int
+	Stored        []int
+	ReachedCutoff bool
+}
+
+// ingestTopicList parses a forum listing page and upserts its topics and their
+// authors. Topics whose last post is before cutoff are skipped unless sticky.
+func (s *Scraper) ingestTopicList(ctx context.Context, forumID, pageNum int, page *Page, cutoff time.Time) (*topicListResult, error) {
+	listing, err := parseTopicList(page.Body, forumID, page.FetchedAt)
+	if err != nil {
+		return nil, &ParseError{Page: page, Err: fmt.Errorf("failed to parse forum %d page %d: %w", forumID, pageNum, err)}
+	}
+
+	ids := make([]int, len(listing.Topics))
+	for i, entry := range listing.Topics {
+		ids[i] = entry.Topic.ID
+	}
+	states, err := s.repo.GetTopicSyncStates(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load topic watermarks: %w", err)
+	}
+
+	result := &topicListResult{LastPage: listing.LastPage}
+	var topics []models.Topic
+	var authors []models.User
+	seen := make(map[int]bool)
+	for i := range listing.Topics {
+		entry := &listing.Topics[i]
+		if beforeCutoff(entry, cutoff) {
+			result.ReachedCutoff = true
+			continue
+		}
+		state, stored := states[entry.Topic.ID]
+		if !stored || hasNewActivity(&entry.Topic, state) {
+			result.Changed = append(result.Changed, entry.Topic.ID)
+		}
+		topics = append(topics, entry.Topic)
+		result.Stored = append(result.Stored, entry.Topic.ID)
+		if !seen[entry.Topic.AuthorID] {
+			seen[entry.Topic.AuthorID] = true
+			authors = append(authors, models.User{ID: entry.Topic.AuthorID, Username: entry.Topic.AuthorName})
+		}
+	}
+
+	if err := s.repo.UpsertUsers(ctx, authors); err != nil {
+		return nil, fmt.Errorf("failed to store topic authors of forum %d page %d: %w", forumID, pageNum, err)
+	}
+	if err := s.repo.UpsertTopics(ctx, topics); err != nil {
+		return nil, fmt.Errorf("failed to store topics of forum %d page %d: %w", forumID, pageNum, err)
+	}
+	countRows(ctx, len(authors)+len(topics))
+	return result, nil
+}
+
+// topicsStored parses a listing page and reports whether every topic on it that
+// ingesting it would store is stored. A listing that does not parse is reported
+// as not stored, so it is ingested and quarantined.
+func (s *Scraper) topicsStored(ctx context.Context, forumID int, page *Page, cutoff time.Time) (*topicListPage, bool, error) {
+	listing, err := parseTopicList(page.Body, forumID, page.FetchedAt)
+	if err != nil {
+		return nil, false, nil
+	}
+	var ids []int
+	for i := range listing.Topics {
+		if entry := &listing.Topics[i]; !beforeCutoff(entry, cutoff) {
+			ids = append(ids, entry.Topic.ID)
+		}
+	}
+	states, err := s.repo.GetTopicSyncStates(ctx, ids)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load topic watermarks: %w", err)
+	}
+	for _, id := range ids {
+		if _, ok := states[id]; !ok {
+			return listing, false, nil
+		}
+	}
+	return listing, true, nil
+}
+
+// beforeCutoff reports whether a listed topic's last post is before cutoff and
+// the topic is not sticky
+func beforeCutoff(entry *topicEntry, cutoff time.Time) bool {
+	return !cutoff.IsZero() && !entry.Sticky && entry.Topic.LastPostAt != nil && entry.Topic.LastPostAt.Before(cutoff)
+}
+
+// hasNewActivity compares a topic from a listing with its stored watermark
+func hasNewActivity(upstream *models.Topic, stored repository.TopicSyncState) bool {
+	if stored.PostCount < upstream.UpstreamReplyCount+1 {
+		return true
+	}
+	if upstream.LastPostAt == nil {
+		return false
+	}
+	return stored.LastPostAt == nil || upstream.LastPostAt.After(*stored.LastPostAt)
+}
+
+// storeTopic upserts a topic together with its author
+func (s *Scraper) storeTopic(ctx context.Context, topic *models.Topic) error {
+	author := &models.User{ID: topic.AuthorID, Username: topic.AuthorName}
+	if err := s.repo.UpsertUser(ctx, author); err != nil {
+		return fmt.Errorf("failed to store user %d: %w", author.ID, err)
+	}
+	if err := s.repo.UpsertTopic(ctx, topic); err != nil {
+		return fmt.Errorf("failed to store topic %d: %w", topic.ID, err)
+	}
+	countRows(ctx, 2)
+	return nil
+}
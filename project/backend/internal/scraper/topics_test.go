@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseTopicList(t *testing.T) {
+	fetchedAt := time.Date(2024, time.May, 20, 12, 0, 0, 0, time.UTC)
+	listing, err := parseTopicList(readFixture(t, "forum_1_page_1.html"), 1, fetchedAt)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if listing.LastPage != 2 {
+		t.Errorf("Expected last page 2, got %d", listing.LastPage)
+	}
+	if len(listing.Topics) != 3 {
+		t.Fatalf("Expected 3 topics, got %d", len(listing.Topics))
+	}
+
+	if !listing.Topics[0].Sticky {
+		t.Error("Expected first topic to be sticky")
+	}
+
+	merge := listing.Topics[1].Topic
+	if merge.ID != 1001 || merge.ForumID != 1 {
+		t.Errorf("Expected topic 1001 in forum 1, got %d in forum %d", merge.ID, merge.ForumID)
+	}
+	if merge.Title != "Как ускорить MERGE на больших таблицах?" {
+		t.Errorf("Unexpected title '%s'", merge.Title)
+	}
+	if merge.AuthorID != 55 || merge.AuthorName != "ivanov" {
+		t.Errorf("Expected author 55 'ivanov', got %d '%s'", merge.AuthorID, merge.AuthorName)
+	}
+	if merge.UpstreamReplyCount != 12 || merge.ViewCount != 345 {
+		t.Errorf("Expected 12 replies and 345 views, got %d and %d", merge.UpstreamReplyCount, merge.ViewCount)
+	}
+	wantLastPost := time.Date(2024, time.May, 20, 11, 32, 0, 0, time.UTC)
+	if merge.LastPostAt == nil || !merge.LastPostAt.Equal(wantLastPost) {
+		t.Errorf("Expected last post at %v, got %v", wantLastPost, merge.LastPostAt)
+	}
+
+	guest := listing.Topics[2].Topic
+	if guest.AuthorID != guestUserID || guest.AuthorName != guestUsername {
+		t.Errorf("Expected guest author, got %d '%s'", guest.AuthorID, guest.AuthorName)
+	}
+}
+
+func TestParseTopicList_UnexpectedMarkup(t *testing.T) {
+	_, err := parseTopicList(readFixture(t, "forum_index.html"), 1, time.Now())
+	if !errors.Is(err, ErrUnexpectedMarkup) {
+		t.Fatalf("Expected ErrUnexpectedMarkup, got %v", err)
+	}
+}
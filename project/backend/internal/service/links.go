@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"forum-api-wrapper/internal/models"
+)
+
+// GetTopicOutlinks retrieves the links from a topic's posts to other topics
+func (s *Service) GetTopicOutlinks(ctx context.Context, id int, page, limit int) (*TopicLinksResponse, error) {
+	if err := s.requireTopic(ctx, id); err != nil {
+		return nil, err
+	}
+
+	links, total, err := s.repo.GetTopicOutlinks(ctx, id, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topic outlinks: %w", err)
+	}
+
+	return &TopicLinksResponse{
+		TopicID:    id,
+		Links:      links,
+		Pagination: models.CalculatePagination(page, limit, total),
+	}, nil
+}
+
+// GetTopicBacklinks retrieves the links to a topic from the posts of other topics
+func (s *Service) GetTopicBacklinks(ctx context.Context, id int, page, limit int) (*TopicLinksResponse, error) {
+	if err := s.requireTopic(ctx, id); err != nil {
+		return nil, err
+	}
+
+	links, total, err := s.repo.GetTopicBacklinks(ctx, id, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topic backlinks: %w", err)
+	}
+
+	return &TopicLinksResponse{
+		TopicID:    id,
+		Links:      links,
+		Pagination: models.CalculatePagination(page, limit, total),
+	}, nil
+}
+
+// requireTopic returns a "topic not found" error unless the topic exists
+func (s *Service) requireTopic(ctx context.Context, id int) error {
+	topic, err := s.repo.GetTopicByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get topic: %w", err)
+	}
+	if topic == nil {
+		return fmt.Errorf("topic not found")
+	}
+	return nil
+}
+
+// TopicLinksResponse is a page of links from or to a topic
+type TopicLinksResponse struct {
+	TopicID    int                `json:"topicId"`
+	Links      []models.TopicLink `json:"links"`
+	Pagination models.Pagination  `json:"pagination"`
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/scraper"
+)
+
+// GetQuarantinedPages retrieves pages the scraper failed to parse, most recently failed first
+func (s *Service) GetQuarantinedPages(ctx context.Context, page, limit int) (*QuarantineListResponse, error) {
+	pages, total, err := s.repo.GetQuarantinedPages(ctx, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined pages: %w", err)
+	}
+
+	return &QuarantineListResponse{
+		Pages:      pages,
+		Pagination: models.CalculatePagination(page, limit, total),
+	}, nil
+}
+
+// GetQuarantinedPage retrieves a quarantined page by ID
+func (s *Service) GetQuarantinedPage(ctx context.Context, id int) (*models.QuarantinedPage, error) {
+	page, err := s.repo.GetQuarantinedPageByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined page: %w", err)
+	}
+	if page == nil {
+		return nil, fmt.Errorf("quarantined page not found")
+	}
+	return page, nil
+}
+
+// RetryQuarantinedPage fetches and ingests a quarantined page again. A page that
+// now parses is released from quarantine; one that still fails stays there with
+// its attempt count increased. The retry is recorded as a sync job on what the
+// page describes, so it is rejected while a running job covers it.
+func (s *Service) RetryQuarantinedPage(ctx context.Context, id int) (*QuarantineRetryResponse, error) {
+	if s.syncer == nil {
+		return nil, fmt.Errorf("sync is not configured")
+	}
+	page, err := s.GetQuarantinedPage(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	req, err := retryRequest(page.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	jobCtx, done, err := s.trackJob(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	job, err := s.createSyncJob(ctx, req, SyncTriggerRetry)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.runSyncJob(jobCtx, job, func(ctx context.Context) error {
+		return s.syncer.RetryPage(ctx, page.Path)
+	})
+	var parseErr *scraper.ParseError
+	if errors.As(err, &parseErr) {
+		if page, err = s.GetQuarantinedPage(ctx, id); err != nil {
+			return nil, err
+		}
+		return &QuarantineRetryResponse{Resolved: false, JobID: job.ID, Page: page}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry page: %w", err)
+	}
+
+	if _, err := s.repo.DeleteQuarantinedPage(ctx, id); err != nil {
+		return nil, fmt.Errorf("failed to release quarantined page: %w", err)
+	}
+	return &QuarantineRetryResponse{Resolved: true, JobID: job.ID}, nil
+}
+
+// retryRequest returns the sync request covering the forum page at path
+func retryRequest(path string) (SyncRequest, error) {
+	subject, id, ok := scraper.PageSubject(path)
+	if !ok {
+		return SyncRequest{}, fmt.Errorf("cannot retry %s: not a known forum page", path)
+	}
+	switch subject {
+	case scraper.SubjectForum:
+		return SyncRequest{Scope: SyncScopeForum, TargetID: &id}, nil
+	case scraper.SubjectTopic:
+		return SyncRequest{Scope: SyncScopeTopic, TargetID: &id}, nil
+	case scraper.SubjectUser:
+		return SyncRequest{Scope: SyncScopeUser, TargetID: &id}, nil
+	default:
+		return SyncRequest{Scope: SyncScopeIndex}, nil
+	}
+}
+
+// DiscardQuarantinedPage removes a page from quarantine without ingesting it
+func (s *Service) DiscardQuarantinedPage(ctx context.Context, id int) error {
+	deleted, err := s.repo.DeleteQuarantinedPage(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to discard quarantined page: %w", err)
+	}
+	if !deleted {
+		return fmt.Errorf("quarantined page not found")
+	}
+	return nil
+}
+
+// QuarantineListResponse is a page of quarantined pages
+type QuarantineListResponse struct {
+	Pages      []models.QuarantinedPage `json:"pages"`
+	Pagination models.Pagination        `json:"pagination"`
+}
+
+// QuarantineRetryResponse is the outcome of retrying a quarantined page
+type QuarantineRetryResponse struct {
+	Resolved bool `json:"resolved"`
+	// JobID is the sync job the retry was recorded as
+	JobID int `json:"jobId"`
+	// Page is the still quarantined page when the retry failed to parse it again
+	Page *models.QuarantinedPage `json:"page,omitempty"`
+}
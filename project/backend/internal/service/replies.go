@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"forum-api-wrapper/internal/models"
+)
+
+// GetPostReplies retrieves the posts that quote a post, oldest first
+func (s *Service) GetPostReplies(ctx context.Context, id int) (*PostRepliesResponse, error) {
+	post, err := s.repo.GetPostByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if post == nil {
+		return nil, fmt.Errorf("post not found")
+	}
+
+	replies, err := s.repo.GetPostReplies(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post replies: %w", err)
+	}
+
+	return &PostRepliesResponse{
+		PostID:  id,
+		Replies: replies,
+	}, nil
+}
+
+// GetTopicTree retrieves a topic with its posts nested as a reply tree. A post
+// is placed under the first earlier post of the topic it quotes; posts quoting
+// none start a thread. Pagination applies to these top-level threads.
+func (s *Service) GetTopicTree(ctx context.Context, id int, page, limit int) (*TopicTreeResponse, error) {
+	topic, err := s.repo.GetTopicByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topic: %w", err)
+	}
+	if topic == nil {
+		return nil, fmt.Errorf("topic not found")
+	}
+
+	posts, err := s.repo.GetAllTopicPosts(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topic posts: %w", err)
+	}
+	quotes, err := s.repo.GetTopicQuotes(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topic quotes: %w", err)
+	}
+
+	roots, children := replyTree(posts, quotes)
+
+	total := len(roots)
+	start := min((page-1)*limit, total)
+	end := min(start+limit, total)
+
+	thread := make([]ReplyNode, 0, end-start)
+	for _, i := range roots[start:end] {
+		thread = append(thread, replyNode(posts, children, i))
+	}
+
+	return &TopicTreeResponse{
+		Topic:            *topic,
+		Thread:           thread,
+		ThreadPagination: models.CalculatePagination(page, limit, total),
+	}, nil
+}
+
+// replyTree links posts, ordered oldest first, to their parents. It returns the
+// indexes of posts without a parent and, per post index, its replies' indexes.
+// Parents always precede their replies, so the tree has no cycles.
+func replyTree(posts []models.Post, quotes []models.PostQuote) ([]int, map[int][]int) {
+	index := make(map[int]int, len(posts))
+	for i, p := range posts {
+		index[p.ID] = i
+	}
+
+	parent := make(map[int]int, len(quotes))
+	for _, q := range quotes {
+		child, ok := index[q.PostID]
+		if !ok {
+			continue
+		}
+		if _, linked := parent[child]; linked {
+			continue
+		}
+		if quoted, ok := index[q.QuotedPostID]; ok && quoted < child {
+			parent[child] = quoted
+		}
+	}
+
+	var roots []int
+	children := make(map[int][]int)
+	for i := range posts {
+		if p, ok := parent[i]; ok {
+			children[p] = append(children[p], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+	return roots, children
+}
+
+// replyNode builds the subtree rooted at posts[i]
+func replyNode(posts []models.Post, children map[int][]int, i int) ReplyNode {
+	node := ReplyNode{Post: posts[i], Replies: make([]ReplyNode, 0, len(children[i]))}
+	for _, c := range children[i] {
+		node.Replies = append(node.Replies, replyNode(posts, children, c))
+	}
+	return node
+}
+
+// FormatTree replaces the content of every post in a reply tree, see FormatPosts
+func FormatTree(nodes []ReplyNode, format string) {
+	for i := range nodes {
+		FormatPost(&nodes[i].Post, format)
+		FormatTree(nodes[i].Replies, format)
+	}
+}
+
+// PostRepliesResponse lists the posts quoting a post
+type PostRepliesResponse struct {
+	PostID  int           `json:"postId"`
+	Replies []models.Post `json:"replies"`
+}
+
+// ReplyNode is a post in a reply tree together with the posts replying to it
+type ReplyNode struct {
+	models.Post
+	Replies []ReplyNode `json:"replies"`
+}
+
+// TopicTreeResponse is a topic with its posts as a reply tree
+type TopicTreeResponse struct {
+	models.Topic
+	Thread           []ReplyNode       `json:"thread"`
+	ThreadPagination models.Pagination `json:"threadPagination"`
+}
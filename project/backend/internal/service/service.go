@@ -3,18 +3,33 @@ package service
 import (
 	"context"
 	"fmt"
+	"forum-api-wrapper/internal/content"
+	"forum-api-wrapper/internal/diff"
 	"forum-api-wrapper/internal/models"
 	"forum-api-wrapper/internal/repository"
+	"strings"
+	"sync"
 )
 
 // Service provides business logic for the API
 type Service struct {
-	repo repository.Repository
+	repo   repository.Repository
+	syncer Syncer
+
+	// jobs tracks running sync jobs and running the targets they claimed (see
+	// syncTargets); stopping is cancelled by Shutdown
+	mu       sync.Mutex
+	closed   bool
+	running  map[string]bool
+	jobs     sync.WaitGroup
+	stopping context.Context
+	stopJobs context.CancelFunc
 }
 
 // NewService creates a new service instance
 func NewService(repo repository.Repository) *Service {
-	return &Service{repo: repo}
+	stopping, stopJobs := context.WithCancel(context.Background())
+	return &Service{repo: repo, running: make(map[string]bool), stopping: stopping, stopJobs: stopJobs}
 }
 
 // GetForums retrieves forums with pagination
@@ -102,6 +117,79 @@ func (s *Service) GetPost(ctx context.Context, id int) (*models.Post, error) {
 	return post, nil
 }
 
+// FormatPosts replaces the content of posts with the requested form: sanitized
+// HTML (as stored), Markdown or plain text. Forms missing from posts stored
+// before they were introduced are derived on the fly.
+func FormatPosts(posts []models.Post, format string) {
+	for i := range posts {
+		FormatPost(&posts[i], format)
+	}
+}
+
+// FormatPost replaces the content of a post with the requested form, see FormatPosts
+func FormatPost(post *models.Post, format string) {
+	switch format {
+	case content.Markdown:
+		if post.ContentMarkdown == "" {
+			post.ContentMarkdown = content.ToMarkdown(post.Content)
+		}
+		post.Content = post.ContentMarkdown
+	case content.Text:
+		if post.ContentText == "" {
+			post.ContentText = content.ToText(post.Content)
+		}
+		post.Content = post.ContentText
+	}
+}
+
+// GetPostRevisions retrieves the earlier versions of a post, each with the diff
+// to the version that replaced it
+func (s *Service) GetPostRevisions(ctx context.Context, id int) (*PostRevisionsResponse, error) {
+	post, err := s.repo.GetPostByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if post == nil {
+		return nil, fmt.Errorf("post not found")
+	}
+
+	revisions, err := s.repo.GetPostRevisions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get post revisions: %w", err)
+	}
+
+	response := &PostRevisionsResponse{
+		PostID:    id,
+		Current:   *post,
+		Revisions: make([]PostRevision, len(revisions)),
+	}
+	for i, rev := range revisions {
+		next := post.Content
+		if i+1 < len(revisions) {
+			next = revisions[i+1].Content
+		}
+		response.Revisions[i] = PostRevision{
+			PostRevision: rev,
+			Diff:         diff.Lines(contentLines(rev.Content), contentLines(next)),
+		}
+	}
+	return response, nil
+}
+
+// contentBreaks are the tags after which stored post HTML is split into lines for diffs
+var contentBreaks = strings.NewReplacer(
+	"<br>", "<br>\n",
+	"</p>", "</p>\n",
+	"</blockquote>", "</blockquote>\n",
+	"</pre>", "</pre>\n",
+	"</li>", "</li>\n",
+)
+
+// contentLines splits stored post HTML into lines at line breaks and block ends
+func contentLines(content string) []string {
+	return strings.Split(strings.TrimSuffix(contentBreaks.Replace(content), "\n"), "\n")
+}
+
 // GetUsers retrieves users with pagination
 func (s *Service) GetUsers(ctx context.Context, page, limit int) (*UserListResponse, error) {
 	users, total, err := s.repo.GetUsers(ctx, page, limit)
@@ -168,6 +256,17 @@ type PostListResponse struct {
 	Pagination models.Pagination `json:"pagination"`
 }
 
+type PostRevision struct {
+	models.PostRevision
+	Diff []diff.Line `json:"diff"`
+}
+
+type PostRevisionsResponse struct {
+	PostID    int            `json:"postId"`
+	Current   models.Post    `json:"current"`
+	Revisions []PostRevision `json:"revisions"`
+}
+
 type UserListResponse struct {
 	Users      []models.User `json:"users"`
 	Pagination models.Pagination `json:"pagination"`
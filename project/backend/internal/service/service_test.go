@@ -2,18 +2,27 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"forum-api-wrapper/internal/models"
 	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scheduler"
+	"strings"
 	"testing"
 	"time"
 )
 
 // mockRepository is a mock implementation of repository.Repository
 type mockRepository struct {
-	forums []models.Forum
-	topics []models.Topic
-	posts  []models.Post
-	users  []models.User
+	forums     []models.Forum
+	topics     []models.Topic
+	posts      []models.Post
+	users      []models.User
+	jobs       []models.SyncJob
+	revisions  []models.PostRevision
+	quarantine []models.QuarantinedPage
+	quotes     []models.PostQuote
+	drift      []models.CounterDrift
 }
 
 func (m *mockRepository) GetForums(ctx context.Context, page, limit int) ([]models.Forum, int, error) {
@@ -29,6 +38,26 @@ func (m *mockRepository) GetForumByID(ctx context.Context, id int) (*models.Foru
 	return nil, nil
 }
 
+func (m *mockRepository) UpsertForums(ctx context.Context, forums []models.Forum) error {
+	for i := range forums {
+		if err := m.UpsertForum(ctx, &forums[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockRepository) UpsertForum(ctx context.Context, forum *models.Forum) error {
+	for i, f := range m.forums {
+		if f.ID == forum.ID {
+			m.forums[i] = *forum
+			return nil
+		}
+	}
+	m.forums = append(m.forums, *forum)
+	return nil
+}
+
 func (m *mockRepository) GetTopics(ctx context.Context, filter repository.TopicFilter, page, limit int) ([]models.Topic, int, error) {
 	return m.topics, len(m.topics), nil
 }
@@ -52,6 +81,45 @@ func (m *mockRepository) GetTopicPosts(ctx context.Context, topicID int, page, l
 	return topicPosts, len(topicPosts), nil
 }
 
+func (m *mockRepository) UpsertTopics(ctx context.Context, topics []models.Topic) error {
+	for i := range topics {
+		if err := m.UpsertTopic(ctx, &topics[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockRepository) UpsertTopic(ctx context.Context, topic *models.Topic) error {
+	for i, t := range m.topics {
+		if t.ID == topic.ID {
+			m.topics[i] = *topic
+			return nil
+		}
+	}
+	m.topics = append(m.topics, *topic)
+	return nil
+}
+
+func (m *mockRepository) GetTopicSyncStates(ctx context.Context, topicIDs []int) (map[int]repository.TopicSyncState, error) {
+	states := make(map[int]repository.TopicSyncState)
+	for _, id := range topicIDs {
+		for _, t := range m.topics {
+			if t.ID != id {
+				continue
+			}
+			state := repository.TopicSyncState{LastPostAt: t.LastPostAt}
+			for _, p := range m.posts {
+				if p.TopicID == id && p.DeletedAt == nil {
+					state.PostCount++
+				}
+			}
+			states[id] = state
+		}
+	}
+	return states, nil
+}
+
 func (m *mockRepository) GetPosts(ctx context.Context, filter repository.PostFilter, page, limit int) ([]models.Post, int, error) {
 	return m.posts, len(m.posts), nil
 }
@@ -65,6 +133,114 @@ func (m *mockRepository) GetPostByID(ctx context.Context, id int) (*models.Post,
 	return nil, nil
 }
 
+func (m *mockRepository) UpsertPosts(ctx context.Context, posts []models.Post) error {
+	for i := range posts {
+		if err := m.UpsertPost(ctx, &posts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockRepository) UpsertPost(ctx context.Context, post *models.Post) error {
+	for i, p := range m.posts {
+		if p.ID == post.ID {
+			m.posts[i] = *post
+			return nil
+		}
+	}
+	m.posts = append(m.posts, *post)
+	return nil
+}
+
+func (m *mockRepository) MarkPostsDeleted(ctx context.Context, topicID int, keepIDs []int) (int, error) {
+	keep := make(map[int]bool, len(keepIDs))
+	for _, id := range keepIDs {
+		keep[id] = true
+	}
+	marked := 0
+	now := time.Now()
+	for i, p := range m.posts {
+		if p.TopicID == topicID && !keep[p.ID] && p.DeletedAt == nil {
+			m.posts[i].DeletedAt = &now
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+func (m *mockRepository) GetPostRevisions(ctx context.Context, postID int) ([]models.PostRevision, error) {
+	var revisions []models.PostRevision
+	for _, r := range m.revisions {
+		if r.PostID == postID {
+			revisions = append(revisions, r)
+		}
+	}
+	return revisions, nil
+}
+
+func (m *mockRepository) GetAllTopicPosts(ctx context.Context, topicID int) ([]models.Post, error) {
+	posts, _, err := m.GetTopicPosts(ctx, topicID, 1, len(m.posts))
+	return posts, err
+}
+
+func (m *mockRepository) ReplacePostQuotes(ctx context.Context, postID int, quotedIDs []int) error {
+	var kept []models.PostQuote
+	for _, q := range m.quotes {
+		if q.PostID != postID {
+			kept = append(kept, q)
+		}
+	}
+	for _, id := range quotedIDs {
+		kept = append(kept, models.PostQuote{PostID: postID, QuotedPostID: id})
+	}
+	m.quotes = kept
+	return nil
+}
+
+func (m *mockRepository) FindQuotedPost(ctx context.Context, topicID int, author string, beforeID int) (int, error) {
+	found := 0
+	for _, p := range m.posts {
+		if p.TopicID == topicID && p.AuthorName == author && p.ID < beforeID && p.ID > found {
+			found = p.ID
+		}
+	}
+	return found, nil
+}
+
+func (m *mockRepository) GetPostReplies(ctx context.Context, postID int) ([]models.Post, error) {
+	var replies []models.Post
+	for _, q := range m.quotes {
+		if q.QuotedPostID == postID {
+			p, _ := m.GetPostByID(ctx, q.PostID)
+			replies = append(replies, *p)
+		}
+	}
+	return replies, nil
+}
+
+func (m *mockRepository) GetTopicQuotes(ctx context.Context, topicID int) ([]models.PostQuote, error) {
+	var quotes []models.PostQuote
+	for _, q := range m.quotes {
+		if p, _ := m.GetPostByID(ctx, q.PostID); p != nil && p.TopicID == topicID {
+			quotes = append(quotes, q)
+		}
+	}
+	return quotes, nil
+}
+
+func (m *mockRepository) ReplacePostLinks(ctx context.Context, postID int, links []models.PostLink) error {
+	return nil
+}
+
+func (m *mockRepository) GetTopicOutlinks(ctx context.Context, topicID int, page, limit int) ([]models.TopicLink, int, error) {
+	return nil, 0, nil
+}
+
+func (m *mockRepository) GetTopicBacklinks(ctx context.Context, topicID int, page, limit int) ([]models.TopicLink, int, error) {
+	return nil, 0, nil
+}
+
 func (m *mockRepository) GetUsers(ctx context.Context, page, limit int) ([]models.User, int, error) {
 	return m.users, len(m.users), nil
 }
@@ -78,6 +254,50 @@ func (m *mockRepository) GetUserByID(ctx context.Context, id int) (*models.User,
 	return nil, nil
 }
 
+func (m *mockRepository) UpsertUsers(ctx context.Context, users []models.User) error {
+	for i := range users {
+		if err := m.UpsertUser(ctx, &users[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockRepository) UpsertUser(ctx context.Context, user *models.User) error {
+	for i, u := range m.users {
+		if u.ID == user.ID {
+			m.users[i].Username = user.Username
+			return nil
+		}
+	}
+	m.users = append(m.users, *user)
+	return nil
+}
+
+func (m *mockRepository) UpdateUserProfile(ctx context.Context, user *models.User) error {
+	for i, u := range m.users {
+		if u.ID == user.ID {
+			now := time.Now()
+			m.users[i] = *user
+			m.users[i].PostCount = u.PostCount
+			m.users[i].TopicCount = u.TopicCount
+			m.users[i].ProfileSyncedAt = &now
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to update user profile: user %d not found", user.ID)
+}
+
+func (m *mockRepository) GetStaleProfiles(ctx context.Context, syncedBefore time.Time, limit int) ([]int, error) {
+	var ids []int
+	for _, u := range m.users {
+		if u.ID > 0 && (u.ProfileSyncedAt == nil || u.ProfileSyncedAt.Before(syncedBefore)) && len(ids) < limit {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids, nil
+}
+
 func (m *mockRepository) Search(ctx context.Context, query string, searchType string, forumID *int, page, limit int) (repository.SearchResults, int, error) {
 	return repository.SearchResults{
 		Topics: m.topics,
@@ -86,6 +306,108 @@ func (m *mockRepository) Search(ctx context.Context, query string, searchType st
 	}, len(m.topics) + len(m.posts) + len(m.users), nil
 }
 
+func (m *mockRepository) CreateSyncJob(ctx context.Context, job *models.SyncJob) error {
+	job.ID = len(m.jobs) + 1
+	m.jobs = append(m.jobs, *job)
+	return nil
+}
+
+func (m *mockRepository) UpdateSyncJob(ctx context.Context, job *models.SyncJob) error {
+	for i, j := range m.jobs {
+		if j.ID == job.ID {
+			m.jobs[i] = *job
+		}
+	}
+	return nil
+}
+
+func (m *mockRepository) GetSyncJobs(ctx context.Context, page, limit int) ([]models.SyncJob, int, error) {
+	return m.jobs, len(m.jobs), nil
+}
+
+func (m *mockRepository) GetSyncJobByID(ctx context.Context, id int) (*models.SyncJob, error) {
+	for _, j := range m.jobs {
+		if j.ID == id {
+			return &j, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) ReconcileCounters(ctx context.Context, jobID int) (int, error) {
+	corrected := 0
+	for i, t := range m.topics {
+		actual := -1
+		for _, p := range m.posts {
+			if p.TopicID == t.ID && p.DeletedAt == nil {
+				actual++
+			}
+		}
+		if actual < 0 {
+			actual = 0
+		}
+		if t.ReplyCount != actual {
+			m.drift = append(m.drift, models.CounterDrift{
+				JobID: jobID, Entity: models.CounterEntityTopic, EntityID: t.ID,
+				Counter: "reply_count", Stored: t.ReplyCount, Actual: actual,
+			})
+			m.topics[i].ReplyCount = actual
+			corrected++
+		}
+	}
+	return corrected, nil
+}
+
+func (m *mockRepository) GetCounterDrift(ctx context.Context, jobID int, page, limit int) ([]models.CounterDrift, int, error) {
+	var drift []models.CounterDrift
+	for _, d := range m.drift {
+		if d.JobID == jobID {
+			drift = append(drift, d)
+		}
+	}
+	return drift, len(drift), nil
+}
+
+func (m *mockRepository) QuarantinePage(ctx context.Context, page *models.QuarantinedPage) error {
+	for i, q := range m.quarantine {
+		if q.Path == page.Path {
+			m.quarantine[i].Error = page.Error
+			m.quarantine[i].Attempts++
+			m.quarantine[i].LastFailedAt = page.LastFailedAt
+			*page = m.quarantine[i]
+			return nil
+		}
+	}
+	page.ID = len(m.quarantine) + 1
+	page.Attempts = 1
+	page.FirstFailedAt = page.LastFailedAt
+	m.quarantine = append(m.quarantine, *page)
+	return nil
+}
+
+func (m *mockRepository) GetQuarantinedPages(ctx context.Context, page, limit int) ([]models.QuarantinedPage, int, error) {
+	return m.quarantine, len(m.quarantine), nil
+}
+
+func (m *mockRepository) GetQuarantinedPageByID(ctx context.Context, id int) (*models.QuarantinedPage, error) {
+	for _, q := range m.quarantine {
+		if q.ID == id {
+			return &q, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockRepository) DeleteQuarantinedPage(ctx context.Context, id int) (bool, error) {
+	for i, q := range m.quarantine {
+		if q.ID == id {
+			m.quarantine = append(m.quarantine[:i], m.quarantine[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func TestService_GetForums(t *testing.T) {
 	mockRepo := &mockRepository{
 		forums: []models.Forum{
@@ -128,6 +450,61 @@ func TestService_GetForum_NotFound(t *testing.T) {
 	}
 }
 
+func TestService_GetTopicTree(t *testing.T) {
+	mockRepo := &mockRepository{
+		topics: []models.Topic{{ID: 1, Title: "Test Topic"}},
+		posts: []models.Post{
+			{ID: 1, TopicID: 1},
+			{ID: 2, TopicID: 1},
+			{ID: 3, TopicID: 1},
+			{ID: 4, TopicID: 1},
+			{ID: 5, TopicID: 1},
+		},
+		quotes: []models.PostQuote{
+			// 3 quotes 2 first, so it replies to 2 rather than 1
+			{PostID: 2, QuotedPostID: 1},
+			{PostID: 3, QuotedPostID: 2},
+			{PostID: 3, QuotedPostID: 1},
+			{PostID: 4, QuotedPostID: 1},
+			// A quote of a post from another topic does not place a post in the tree
+			{PostID: 5, QuotedPostID: 99},
+		},
+	}
+	svc := NewService(mockRepo)
+
+	response, err := svc.GetTopicTree(context.Background(), 1, 1, 20)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var shape func(nodes []ReplyNode) string
+	shape = func(nodes []ReplyNode) string {
+		var parts []string
+		for _, n := range nodes {
+			part := fmt.Sprint(n.ID)
+			if len(n.Replies) > 0 {
+				part += "(" + shape(n.Replies) + ")"
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, " ")
+	}
+	if got, want := shape(response.Thread), "1(2(3) 4) 5"; got != want {
+		t.Errorf("Expected tree %s, got %s", want, got)
+	}
+	if response.ThreadPagination.Total != 2 {
+		t.Errorf("Expected 2 threads, got %d", response.ThreadPagination.Total)
+	}
+
+	response, err = svc.GetTopicTree(context.Background(), 1, 2, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := shape(response.Thread); got != "5" {
+		t.Errorf("Expected second page to hold thread 5, got %s", got)
+	}
+}
+
 func TestService_GetTopics(t *testing.T) {
 	now := time.Now()
 	mockRepo := &mockRepository{
@@ -158,3 +535,252 @@ func TestService_GetTopics(t *testing.T) {
 		t.Errorf("Expected topic title 'Test Topic', got '%s'", response.Topics[0].Title)
 	}
 }
+
+// mockSyncer records the forums it was asked to sync
+type mockSyncer struct {
+	forums []int
+	err    error
+}
+
+func (m *mockSyncer) FullSync(ctx context.Context) error                 { return m.err }
+func (m *mockSyncer) SyncForums(ctx context.Context) error               { return m.err }
+func (m *mockSyncer) ResyncPosts(ctx context.Context, topicID int) error { return m.err }
+func (m *mockSyncer) SyncProfiles(ctx context.Context) error             { return m.err }
+func (m *mockSyncer) SyncProfile(ctx context.Context, userID int) error  { return m.err }
+func (m *mockSyncer) RetryPage(ctx context.Context, path string) error   { return m.err }
+
+func (m *mockSyncer) SyncForum(ctx context.Context, forumID int) error {
+	m.forums = append(m.forums, forumID)
+	return m.err
+}
+
+func TestService_RunSync(t *testing.T) {
+	mockRepo := &mockRepository{}
+	syncer := &mockSyncer{err: errors.New("forum 3: boom\nforum 5: boom")}
+	svc := NewService(mockRepo)
+	svc.SetSyncer(syncer)
+
+	ctx := context.Background()
+	forumID := 3
+	job, err := svc.RunSync(ctx, SyncRequest{Scope: SyncScopeForum, TargetID: &forumID}, SyncTriggerManual)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(syncer.forums) != 1 || syncer.forums[0] != 3 {
+		t.Errorf("Expected forum 3 to be synced, got %v", syncer.forums)
+	}
+
+	stored, err := svc.GetSyncJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if stored.Status != models.SyncJobFailed {
+		t.Errorf("Expected status '%s', got '%s'", models.SyncJobFailed, stored.Status)
+	}
+
+	if len(stored.Errors) != 2 {
+		t.Errorf("Expected 2 errors, got %v", stored.Errors)
+	}
+
+	if stored.FinishedAt == nil {
+		t.Error("Expected finished job to have FinishedAt set")
+	}
+}
+
+func TestService_RunSync_ReconcilesCounters(t *testing.T) {
+	mockRepo := &mockRepository{
+		topics: []models.Topic{{ID: 1, ReplyCount: 12}, {ID: 2, ReplyCount: 1}},
+		posts: []models.Post{
+			{ID: 10, TopicID: 1}, {ID: 11, TopicID: 1},
+			{ID: 20, TopicID: 2}, {ID: 21, TopicID: 2},
+		},
+	}
+	svc := NewService(mockRepo)
+
+	// Reconciling counters needs no syncer
+	ctx := context.Background()
+	job, err := svc.RunSync(ctx, SyncRequest{Scope: SyncScopeCounters}, SyncTriggerManual)
+
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if job.Status != models.SyncJobSucceeded || job.CountersCorrected != 1 {
+		t.Errorf("Expected a succeeded job with 1 corrected counter, got %s with %d", job.Status, job.CountersCorrected)
+	}
+
+	if mockRepo.topics[0].ReplyCount != 1 {
+		t.Errorf("Expected reply count 1, got %d", mockRepo.topics[0].ReplyCount)
+	}
+
+	response, err := svc.GetSyncJobDrift(ctx, job.ID, 1, 20)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(response.Drift) != 1 || response.Drift[0].EntityID != 1 || response.Drift[0].Stored != 12 {
+		t.Errorf("Expected drift of topic 1 from 12, got %+v", response.Drift)
+	}
+
+	_, err = svc.GetSyncJobDrift(ctx, 99, 1, 20)
+	if err == nil || err.Error() != "sync job not found" {
+		t.Errorf("Expected 'sync job not found' error, got %v", err)
+	}
+}
+
+func TestService_StartSync_Rejected(t *testing.T) {
+	svc := NewService(&mockRepository{})
+
+	ctx := context.Background()
+	_, err := svc.StartSync(ctx, SyncRequest{Scope: SyncScopeFull})
+
+	if err == nil || err.Error() != "sync is not configured" {
+		t.Errorf("Expected 'sync is not configured' error, got %v", err)
+	}
+
+	svc.SetSyncer(&mockSyncer{})
+	_, err = svc.StartSync(ctx, SyncRequest{Scope: SyncScopeTopic})
+
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid sync request") {
+		t.Errorf("Expected invalid sync request error, got %v", err)
+	}
+}
+
+// blockingSyncer syncs until its context is cancelled
+type blockingSyncer struct {
+	mockSyncer
+	started chan struct{}
+}
+
+func (m *blockingSyncer) FullSync(ctx context.Context) error {
+	close(m.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestService_Shutdown(t *testing.T) {
+	mockRepo := &mockRepository{}
+	syncer := &blockingSyncer{started: make(chan struct{})}
+	svc := NewService(mockRepo)
+	svc.SetSyncer(syncer)
+
+	// The job outlives the request that started it, but not Shutdown
+	reqCtx, cancelReq := context.WithCancel(context.Background())
+	job, err := svc.StartSync(reqCtx, SyncRequest{Scope: SyncScopeFull})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	cancelReq()
+	<-syncer.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := svc.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	stored, err := svc.GetSyncJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stored.Status != models.SyncJobFailed || stored.FinishedAt == nil {
+		t.Errorf("Expected the cancelled job recorded as failed, got %+v", stored)
+	}
+
+	_, err = svc.RunSync(context.Background(), SyncRequest{Scope: SyncScopeFull}, SyncTriggerScheduled)
+	if err == nil || err.Error() != "sync is shutting down" {
+		t.Errorf("Expected 'sync is shutting down' error, got %v", err)
+	}
+}
+
+func TestService_RejectsOverlappingSyncs(t *testing.T) {
+	mockRepo := &mockRepository{
+		topics:     []models.Topic{{ID: 1, ForumID: 1}},
+		quarantine: []models.QuarantinedPage{{ID: 1, Path: "/topic.php?tid=1&p=2"}},
+	}
+	syncer := &blockingSyncer{started: make(chan struct{})}
+	svc := NewService(mockRepo)
+	svc.SetSyncer(syncer)
+
+	ctx := context.Background()
+	if _, err := svc.StartSync(ctx, SyncRequest{Scope: SyncScopeFull}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	<-syncer.started
+
+	// The full walk covers the index, every forum and every topic, stored or not
+	forumID, topicID, unknownTopicID := 1, 1, 99
+	for _, req := range []SyncRequest{
+		{Scope: SyncScopeFull},
+		{Scope: SyncScopeIndex},
+		{Scope: SyncScopeForum, TargetID: &forumID},
+		{Scope: SyncScopeTopic, TargetID: &topicID},
+		{Scope: SyncScopeTopic, TargetID: &unknownTopicID},
+	} {
+		_, err := svc.StartSync(ctx, req)
+		if !errors.Is(err, ErrSyncRunning) {
+			t.Errorf("StartSync(%s): expected ErrSyncRunning, got %v", req.Scope, err)
+		}
+		_, err = svc.RunSync(ctx, req, SyncTriggerScheduled)
+		if !errors.Is(err, ErrSyncRunning) {
+			t.Errorf("RunSync(%s): expected ErrSyncRunning, got %v", req.Scope, err)
+		}
+	}
+
+	// So is the retry of a page of one of its topics
+	if _, err := svc.RetryQuarantinedPage(ctx, 1); !errors.Is(err, ErrSyncRunning) {
+		t.Errorf("RetryQuarantinedPage: expected ErrSyncRunning, got %v", err)
+	}
+
+	// The scheduler is told its run was skipped rather than failed
+	if err := svc.ScheduledSyncer().SyncForum(ctx, forumID); !errors.Is(err, scheduler.ErrSkipped) {
+		t.Errorf("Expected scheduled forum sync to be skipped, got %v", err)
+	}
+
+	// Other targets run alongside, and again once finished
+	userID := 1
+	for i := 0; i < 2; i++ {
+		job, err := svc.RunSync(ctx, SyncRequest{Scope: SyncScopeUser, TargetID: &userID}, SyncTriggerManual)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if job.Status != models.SyncJobSucceeded {
+			t.Errorf("Expected job to succeed, got %+v", job)
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := svc.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+func TestTargetsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"forums", "forums", true},
+		{"forums", "forums/1", true},
+		{"forums/1", "forums/1/1001", true},
+		{"forums/1", "forums/2", false},
+		{"forums/1/1001", "forums/2/1002", false},
+		{"forums/*/1001", "forums/1/1001", true},
+		{"forums/*/1001", "forums/2", true},
+		{"forums/*/1001", "forums/1/1002", false},
+		{"index", "forums", false},
+		{"users", "users/1", true},
+	}
+	for _, tt := range tests {
+		if got := targetsOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("targetsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := targetsOverlap(tt.b, tt.a); got != tt.want {
+			t.Errorf("targetsOverlap(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
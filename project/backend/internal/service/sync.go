@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/scheduler"
+	"forum-api-wrapper/internal/scraper"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sync scopes
+const (
+	SyncScopeFull     = "full"
+	SyncScopeIndex    = "index"
+	SyncScopeForum    = "forum"
+	SyncScopeTopic    = "topic"
+	SyncScopeProfiles = "profiles"
+	SyncScopeUser     = "user"
+	// SyncScopeCounters only reconciles the stored counters
+	SyncScopeCounters = "counters"
+)
+
+// Sync triggers
+const (
+	SyncTriggerManual    = "manual"
+	SyncTriggerScheduled = "scheduled"
+	// SyncTriggerRetry marks the retry of a quarantined page
+	SyncTriggerRetry = "retry"
+)
+
+// ErrSyncRunning rejects a sync job overlapping one already running
+var ErrSyncRunning = errors.New("sync already running")
+
+// syncProgressInterval is how often a running job's counters are saved
+const syncProgressInterval = 5 * time.Second
+
+// Syncer is the part of the scraper the service runs sync jobs with
+type Syncer interface {
+	FullSync(ctx context.Context) error
+	SyncForums(ctx context.Context) error
+	SyncForum(ctx context.Context, forumID int) error
+	ResyncPosts(ctx context.Context, topicID int) error
+	SyncProfiles(ctx context.Context) error
+	SyncProfile(ctx context.Context, userID int) error
+	RetryPage(ctx context.Context, path string) error
+}
+
+// SyncRequest describes what a sync job should cover
+type SyncRequest struct {
+	Scope    string `json:"scope"`
+	TargetID *int   `json:"targetId,omitempty"`
+}
+
+// SetSyncer enables sync jobs; without a syncer they are rejected
+func (s *Service) SetSyncer(syncer Syncer) {
+	s.syncer = syncer
+}
+
+// StartSync records a new sync job and runs it in the background. The job keeps
+// running after ctx is done, so it is not tied to the request that started it;
+// only Shutdown cancels it. A job is rejected while a running one covers part
+// of what it would sync.
+func (s *Service) StartSync(ctx context.Context, req SyncRequest) (*models.SyncJob, error) {
+	jobCtx, done, err := s.trackJob(context.WithoutCancel(ctx), req)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.createSyncJob(ctx, req, SyncTriggerManual)
+	if err != nil {
+		done()
+		return nil, err
+	}
+
+	started := *job
+	go func() {
+		defer done()
+		s.runSyncJob(jobCtx, job, func(ctx context.Context) error {
+			return s.runSync(ctx, req)
+		})
+	}()
+	return &started, nil
+}
+
+// RunSync records a sync job and runs it to completion, or until ctx is done
+// or Shutdown is called. Like StartSync, it rejects a job overlapping a running one.
+func (s *Service) RunSync(ctx context.Context, req SyncRequest, trigger string) (*models.SyncJob, error) {
+	jobCtx, done, err := s.trackJob(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	job, err := s.createSyncJob(ctx, req, trigger)
+	if err != nil {
+		return nil, err
+	}
+	s.runSyncJob(jobCtx, job, func(ctx context.Context) error {
+		return s.runSync(ctx, req)
+	})
+	return job, nil
+}
+
+// Shutdown cancels running sync jobs and waits until they have recorded their
+// outcome, or until ctx is done. Sync jobs started afterwards are rejected.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.stopJobs()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop sync jobs: %w", ctx.Err())
+	}
+}
+
+// trackJob validates req and registers a sync job about to run it, unless a
+// running job claimed one of its targets. The returned context is ctx,
+// cancelled as well when Shutdown is called; done must be called once the job
+// has finished.
+func (s *Service) trackJob(ctx context.Context, req SyncRequest) (context.Context, func(), error) {
+	if err := validateSyncRequest(req); err != nil {
+		return nil, nil, err
+	}
+	targets, err := s.syncTargets(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, nil, fmt.Errorf("sync is shutting down")
+	}
+	for _, target := range targets {
+		for claimed := range s.running {
+			if targetsOverlap(target, claimed) {
+				return nil, nil, fmt.Errorf("%w: %s overlaps %s", ErrSyncRunning, target, claimed)
+			}
+		}
+	}
+
+	for _, target := range targets {
+		s.running[target] = true
+	}
+	s.jobs.Add(1)
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(s.stopping, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		s.mu.Lock()
+		for _, target := range targets {
+			delete(s.running, target)
+		}
+		s.mu.Unlock()
+		s.jobs.Done()
+	}, nil
+}
+
+// syncTargets names what a job for req writes, as paths claiming everything
+// below them: the full walk claims "index" and "forums", a forum
+// "forums/<id>" and a topic "forums/<forum>/<id>". A topic not stored yet may
+// belong to any forum, so its forum is the wildcard "*".
+func (s *Service) syncTargets(ctx context.Context, req SyncRequest) ([]string, error) {
+	switch req.Scope {
+	case SyncScopeFull:
+		return []string{"index", "forums"}, nil
+	case SyncScopeIndex:
+		return []string{"index"}, nil
+	case SyncScopeForum:
+		return []string{fmt.Sprintf("forums/%d", *req.TargetID)}, nil
+	case SyncScopeTopic:
+		forum := "*"
+		topic, err := s.repo.GetTopicByID(ctx, *req.TargetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get topic: %w", err)
+		}
+		if topic != nil {
+			forum = strconv.Itoa(topic.ForumID)
+		}
+		return []string{fmt.Sprintf("forums/%s/%d", forum, *req.TargetID)}, nil
+	case SyncScopeProfiles:
+		return []string{"users"}, nil
+	case SyncScopeUser:
+		return []string{fmt.Sprintf("users/%d", *req.TargetID)}, nil
+	default:
+		return []string{req.Scope}, nil
+	}
+}
+
+// targetsOverlap reports whether one of two targets lies below the other
+func targetsOverlap(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] && as[i] != "*" && bs[i] != "*" {
+			return false
+		}
+	}
+	return true
+}
+
+// GetSyncJobs retrieves sync jobs with pagination, most recent first
+func (s *Service) GetSyncJobs(ctx context.Context, page, limit int) (*SyncJobListResponse, error) {
+	jobs, total, err := s.repo.GetSyncJobs(ctx, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync jobs: %w", err)
+	}
+
+	return &SyncJobListResponse{
+		Jobs:       jobs,
+		Pagination: models.CalculatePagination(page, limit, total),
+	}, nil
+}
+
+// GetSyncJob retrieves a sync job by ID
+func (s *Service) GetSyncJob(ctx context.Context, id int) (*models.SyncJob, error) {
+	job, err := s.repo.GetSyncJobByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync job: %w", err)
+	}
+	if job == nil {
+		return nil, fmt.Errorf("sync job not found")
+	}
+	return job, nil
+}
+
+// GetSyncJobDrift retrieves the counters a sync job found drifted and corrected
+func (s *Service) GetSyncJobDrift(ctx context.Context, jobID int, page, limit int) (*CounterDriftResponse, error) {
+	job, err := s.repo.GetSyncJobByID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync job: %w", err)
+	}
+	if job == nil {
+		return nil, fmt.Errorf("sync job not found")
+	}
+
+	drift, total, err := s.repo.GetCounterDrift(ctx, jobID, page, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get counter drift: %w", err)
+	}
+
+	return &CounterDriftResponse{
+		JobID:      jobID,
+		Drift:      drift,
+		Pagination: models.CalculatePagination(page, limit, total),
+	}, nil
+}
+
+// ScheduledSyncer returns a syncer for the scheduler that records every run as a job
+func (s *Service) ScheduledSyncer() *ScheduledSyncer {
+	return &ScheduledSyncer{service: s}
+}
+
+// ScheduledSyncer runs scheduled syncs through the service so they are recorded
+type ScheduledSyncer struct {
+	service *Service
+}
+
+// SyncForums syncs the forum index
+func (r *ScheduledSyncer) SyncForums(ctx context.Context) error {
+	return r.run(ctx, SyncRequest{Scope: SyncScopeIndex})
+}
+
+// SyncForum syncs one forum
+func (r *ScheduledSyncer) SyncForum(ctx context.Context, forumID int) error {
+	return r.run(ctx, SyncRequest{Scope: SyncScopeForum, TargetID: &forumID})
+}
+
+// FullSync walks the whole forum
+func (r *ScheduledSyncer) FullSync(ctx context.Context) error {
+	return r.run(ctx, SyncRequest{Scope: SyncScopeFull})
+}
+
+// SyncProfiles refreshes a batch of stale user profiles
+func (r *ScheduledSyncer) SyncProfiles(ctx context.Context) error {
+	return r.run(ctx, SyncRequest{Scope: SyncScopeProfiles})
+}
+
+// run records and runs a scheduled job, reporting its failure as an error. A
+// job overlapping a running one is reported as skipped.
+func (r *ScheduledSyncer) run(ctx context.Context, req SyncRequest) error {
+	job, err := r.service.RunSync(ctx, req, SyncTriggerScheduled)
+	if errors.Is(err, ErrSyncRunning) {
+		return fmt.Errorf("%w: %v", scheduler.ErrSkipped, err)
+	}
+	if err != nil {
+		return err
+	}
+	if job.Status == models.SyncJobFailed {
+		return fmt.Errorf("sync job %d failed: %s", job.ID, strings.Join(job.Errors, "; "))
+	}
+	return nil
+}
+
+// createSyncJob records req, validated by trackJob, as a running job
+func (s *Service) createSyncJob(ctx context.Context, req SyncRequest, trigger string) (*models.SyncJob, error) {
+	if s.syncer == nil && req.Scope != SyncScopeCounters {
+		return nil, fmt.Errorf("sync is not configured")
+	}
+
+	job := &models.SyncJob{
+		Scope:     req.Scope,
+		TargetID:  req.TargetID,
+		Trigger:   trigger,
+		Status:    models.SyncJobRunning,
+		StartedAt: time.Now().UTC(),
+		Errors:    []string{},
+	}
+	if err := s.repo.CreateSyncJob(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create sync job: %w", err)
+	}
+	return job, nil
+}
+
+// validateSyncRequest checks that req names a known scope and a target when the scope needs one
+func validateSyncRequest(req SyncRequest) error {
+	switch req.Scope {
+	case SyncScopeFull, SyncScopeIndex, SyncScopeProfiles, SyncScopeCounters:
+		if req.TargetID != nil {
+			return fmt.Errorf("invalid sync request: scope %s takes no target", req.Scope)
+		}
+	case SyncScopeForum, SyncScopeTopic, SyncScopeUser:
+		if req.TargetID == nil || *req.TargetID <= 0 {
+			return fmt.Errorf("invalid sync request: scope %s needs a targetId", req.Scope)
+		}
+	default:
+		return fmt.Errorf("invalid sync request: unknown scope %q", req.Scope)
+	}
+	return nil
+}
+
+// runSyncJob runs job through run and records its progress and outcome. It
+// returns the error of run, which the job records as its errors.
+func (s *Service) runSyncJob(ctx context.Context, job *models.SyncJob, run func(ctx context.Context) error) error {
+	stats := &scraper.Stats{}
+	done := make(chan struct{})
+	progressSaved := make(chan struct{})
+	go func() {
+		defer close(progressSaved)
+		ticker := time.NewTicker(syncProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				progress := *job
+				progress.PagesFetched = stats.PagesFetched()
+				progress.RowsUpserted = stats.RowsUpserted()
+				if err := s.repo.UpdateSyncJob(ctx, &progress); err != nil {
+					log.Printf("sync job %d: failed to save progress: %v", job.ID, err)
+				}
+			}
+		}
+	}()
+
+	syncErr := run(scraper.WithStats(ctx, stats))
+	close(done)
+	<-progressSaved
+
+	finishedAt := time.Now().UTC()
+	job.FinishedAt = &finishedAt
+	job.PagesFetched = stats.PagesFetched()
+	job.RowsUpserted = stats.RowsUpserted()
+	job.Status = models.SyncJobSucceeded
+	if syncErr != nil {
+		job.Status = models.SyncJobFailed
+		job.Errors = strings.Split(syncErr.Error(), "\n")
+	}
+
+	// Even a failed or cancelled sync may have stored rows, so counters are
+	// always reconciled
+	corrected, err := s.repo.ReconcileCounters(context.WithoutCancel(ctx), job.ID)
+	if err != nil {
+		job.Status = models.SyncJobFailed
+		job.Errors = append(job.Errors, err.Error())
+	}
+	job.CountersCorrected = corrected
+	if corrected > 0 {
+		log.Printf("sync job %d: corrected %d drifted counters", job.ID, corrected)
+	}
+
+	// Record the outcome even when the sync was cancelled
+	if err := s.repo.UpdateSyncJob(context.WithoutCancel(ctx), job); err != nil {
+		log.Printf("sync job %d: failed to save outcome: %v", job.ID, err)
+	}
+	return syncErr
+}
+
+// runSync dispatches req to the syncer
+func (s *Service) runSync(ctx context.Context, req SyncRequest) error {
+	switch req.Scope {
+	case SyncScopeIndex:
+		return s.syncer.SyncForums(ctx)
+	case SyncScopeForum:
+		return s.syncer.SyncForum(ctx, *req.TargetID)
+	case SyncScopeTopic:
+		// A requested topic sync rereads every page, catching edits and deletions
+		return s.syncer.ResyncPosts(ctx, *req.TargetID)
+	case SyncScopeProfiles:
+		return s.syncer.SyncProfiles(ctx)
+	case SyncScopeUser:
+		return s.syncer.SyncProfile(ctx, *req.TargetID)
+	case SyncScopeCounters:
+		// Reconciliation follows every job, so there is nothing to sync
+		return nil
+	default:
+		return s.syncer.FullSync(ctx)
+	}
+}
+
+// SyncJobListResponse is a page of sync jobs
+type SyncJobListResponse struct {
+	Jobs       []models.SyncJob  `json:"jobs"`
+	Pagination models.Pagination `json:"pagination"`
+}
+
+// CounterDriftResponse is a page of the counters a sync job corrected
+type CounterDriftResponse struct {
+	JobID      int                   `json:"jobId"`
+	Drift      []models.CounterDrift `json:"drift"`
+	Pagination models.Pagination     `json:"pagination"`
+}
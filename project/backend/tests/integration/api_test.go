@@ -1,6 +1,7 @@
 package integration
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"forum-api-wrapper/internal/api"
+	"forum-api-wrapper/internal/migrate"
 	"forum-api-wrapper/internal/repository"
 	"forum-api-wrapper/internal/service"
 )
@@ -19,53 +21,13 @@ import (
 func setupTestDB(t *testing.T) *sql.DB {
 	db, err := sql.Open("sqlite3", ":memory:")
 	require.NoError(t, err)
+	// Every connection to :memory: opens a new empty database
+	db.SetMaxOpenConns(1)
 
 	// Run migrations
-	schema := `
-	CREATE TABLE forums (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL,
-		description TEXT,
-		topic_count INTEGER DEFAULT 0,
-		post_count INTEGER DEFAULT 0,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE users (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		username TEXT NOT NULL UNIQUE,
-		post_count INTEGER DEFAULT 0,
-		topic_count INTEGER DEFAULT 0,
-		registered_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		last_active_at DATETIME
-	);
-
-	CREATE TABLE topics (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		title TEXT NOT NULL,
-		forum_id INTEGER NOT NULL REFERENCES forums(id),
-		author_id INTEGER NOT NULL REFERENCES users(id),
-		reply_count INTEGER DEFAULT 0,
-		view_count INTEGER DEFAULT 0,
-		last_post_id INTEGER,
-		last_post_at DATETIME,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE posts (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		topic_id INTEGER NOT NULL REFERENCES topics(id),
-		author_id INTEGER NOT NULL REFERENCES users(id),
-		content TEXT NOT NULL,
-		is_first_post INTEGER DEFAULT 0,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-	`
-
-	_, err = db.Exec(schema)
+	migrator, err := migrate.NewMigrator(db, "sqlite3")
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
 	require.NoError(t, err)
 
 	// Insert test data
@@ -84,26 +46,33 @@ func setupTestServer(t *testing.T) *httptest.Server {
 	db := setupTestDB(t)
 	repo := repository.NewRepository(db)
 	svc := service.NewService(repo)
-	handler := api.NewHandler(svc)
+	return httptest.NewServer(setupRouter(api.NewHandler(svc)))
+}
+
+// testAdminToken is the admin token of the router setupRouter builds
+const testAdminToken = "test-admin-token"
 
+func setupRouter(handler *api.Handler) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
-	
-	apiGroup := router.Group("/api")
-	{
-		apiGroup.GET("/health", handler.HealthCheck)
-		apiGroup.GET("/forums", handler.GetForums)
-		apiGroup.GET("/forums/:id", handler.GetForum)
-		apiGroup.GET("/topics", handler.GetTopics)
-		apiGroup.GET("/topics/:topicId", handler.GetTopic)
-		apiGroup.GET("/posts", handler.GetPosts)
-		apiGroup.GET("/posts/:postId", handler.GetPost)
-		apiGroup.GET("/users", handler.GetUsers)
-		apiGroup.GET("/users/:userId", handler.GetUser)
-		apiGroup.GET("/search", handler.Search)
-	}
 
-	return httptest.NewServer(router)
+	api.RegisterRoutes(router, handler, testAdminToken)
+
+	return router
+}
+
+// adminClient sends requests with the admin token
+var adminClient = &http.Client{Transport: bearerTransport{token: testAdminToken}}
+
+// bearerTransport adds a bearer token to every request
+type bearerTransport struct {
+	token string
+}
+
+func (t bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return http.DefaultTransport.RoundTrip(req)
 }
 
 func TestHealthCheck(t *testing.T) {
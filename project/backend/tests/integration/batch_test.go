@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/repository"
+)
+
+func TestBatchUpserts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	ctx := context.Background()
+
+	require.NoError(t, repo.UpsertForums(ctx, []models.Forum{
+		{ID: 2, Name: "PostgreSQL"},
+		{ID: 1, Name: "Microsoft SQL Server", UpstreamTopicCount: 10},
+	}))
+	require.NoError(t, repo.UpsertUsers(ctx, []models.User{
+		{ID: 55, Username: "ivanov"},
+		{ID: 17, Username: "aleks2"},
+	}))
+	require.NoError(t, repo.UpsertTopics(ctx, []models.Topic{
+		{ID: 1001, Title: "MERGE", ForumID: 1, AuthorID: 55},
+		{ID: 1002, Title: "VACUUM", ForumID: 2, AuthorID: 17},
+	}))
+	created := time.Date(2019, time.March, 12, 14, 45, 0, 0, time.UTC)
+	require.NoError(t, repo.UpsertPosts(ctx, []models.Post{
+		{ID: 500002, TopicID: 1001, AuthorID: 17, Content: "Бейте на пачки", CreatedAt: created.Add(time.Minute)},
+		{ID: 500001, TopicID: 1001, AuthorID: 55, Content: "MERGE идёт час", IsFirstPost: true, CreatedAt: created},
+	}))
+
+	forum, err := repo.GetForumByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Microsoft SQL Server", forum.Name)
+	assert.Equal(t, 10, forum.UpstreamTopicCount)
+
+	topic, err := repo.GetTopicByID(ctx, 1002)
+	require.NoError(t, err)
+	assert.Equal(t, "VACUUM", topic.Title)
+	assert.Equal(t, "aleks2", topic.AuthorName)
+
+	posts, total, err := repo.GetTopicPosts(ctx, 1001, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, 500001, posts[0].ID)
+
+	// Upserting again updates rows in place and keeps edited content as a revision
+	require.NoError(t, repo.UpsertPosts(ctx, []models.Post{
+		{ID: 500001, TopicID: 1001, AuthorID: 55, Content: "MERGE идёт два часа", IsFirstPost: true, CreatedAt: created},
+	}))
+	post, err := repo.GetPostByID(ctx, 500001)
+	require.NoError(t, err)
+	assert.Equal(t, "MERGE идёт два часа", post.Content)
+	revisions, err := repo.GetPostRevisions(ctx, 500001)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "MERGE идёт час", revisions[0].Content)
+
+	// Users are keyed by ID only: guests share a placeholder name a member may
+	// also have, and renamed users can swap names within a batch
+	require.NoError(t, repo.UpsertUsers(ctx, []models.User{
+		{ID: 0, Username: "guest"},
+		{ID: 60, Username: "guest"},
+		{ID: 55, Username: "aleks2"},
+		{ID: 17, Username: "ivanov"},
+	}))
+	user, err := repo.GetUserByID(ctx, 55)
+	require.NoError(t, err)
+	assert.Equal(t, "aleks2", user.Username)
+
+	// A failing row rolls back the whole batch
+	_, err = db.Exec(`CREATE TRIGGER reject_user BEFORE INSERT ON users WHEN NEW.id = 91
+		BEGIN SELECT RAISE(ABORT, 'rejected'); END`)
+	require.NoError(t, err)
+	err = repo.UpsertUsers(ctx, []models.User{
+		{ID: 90, Username: "newcomer"},
+		{ID: 91, Username: "petrov"},
+	})
+	require.Error(t, err)
+	user, err = repo.GetUserByID(ctx, 90)
+	require.NoError(t, err)
+	assert.Nil(t, user)
+
+	// Empty batches are no-ops
+	require.NoError(t, repo.UpsertPosts(ctx, nil))
+}
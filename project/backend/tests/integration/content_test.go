@@ -0,0 +1,117 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"forum-api-wrapper/internal/api"
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scraper"
+	"forum-api-wrapper/internal/service"
+)
+
+func TestContentFormats(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	require.NoError(t, scraper.NewScraper(forum.URL, repo, scraper.Options{}).SyncPosts(context.Background(), 1001))
+
+	server := httptest.NewServer(setupRouter(api.NewHandler(service.NewService(repo))))
+	defer server.Close()
+
+	getPost := func(query string) (int, models.Post) {
+		resp, err := http.Get(server.URL + "/api/posts/500002" + query)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var post models.Post
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&post))
+		}
+		return resp.StatusCode, post
+	}
+
+	status, post := getPost("")
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, post.Content, `<a href="https://learn.microsoft.com/sql/t-sql/statements/merge-transact-sql">документацию</a>`)
+	assert.NotContains(t, post.Content, "onclick")
+
+	status, post = getPost("?format=markdown")
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, post.Content, "> **ivanov**:")
+	assert.Contains(t, post.Content, "```sql\nWHILE 1 = 1\nBEGIN")
+	assert.Contains(t, post.Content, "[документацию](https://learn.microsoft.com/sql/t-sql/statements/merge-transact-sql)")
+
+	status, post = getPost("?format=text")
+	require.Equal(t, http.StatusOK, status)
+	assert.Contains(t, post.Content, "См. документацию.")
+	assert.Contains(t, post.Content, "t.Val <> s.Val")
+	assert.NotContains(t, post.Content, "<blockquote>")
+
+	status, _ = getPost("?format=pdf")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// Search matches the plain text, so markup inside a phrase does not hide it
+	resp, err := http.Get(server.URL + "/api/search?type=posts&format=text&q=" + url.QueryEscape("Как ускорить?"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var results service.SearchResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&results))
+	require.Len(t, results.Results.Posts, 1)
+	assert.Equal(t, 500001, results.Results.Posts[0].ID)
+	assert.NotContains(t, results.Results.Posts[0].Content, "<strong>")
+}
+
+func TestSearch_IgnoresCaseOfCyrillicText(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	ctx := context.Background()
+	require.NoError(t, scraper.NewScraper(forum.URL, repo, scraper.Options{}).SyncPosts(ctx, 1001))
+	require.NoError(t, repo.UpsertUsers(ctx, []models.User{{ID: 2, Username: "Иванов"}}))
+	require.NoError(t, repo.UpsertTopics(ctx, []models.Topic{{ID: 2, Title: "Медленный MERGE", ForumID: 1, AuthorID: 2}}))
+
+	server := httptest.NewServer(setupRouter(api.NewHandler(service.NewService(repo))))
+	defer server.Close()
+
+	search := func(searchType, query string) service.SearchResults {
+		resp, err := http.Get(server.URL + "/api/search?type=" + searchType + "&q=" + url.QueryEscape(query))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var results service.SearchResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&results))
+		return results.Results
+	}
+
+	posts := search("posts", "КАК УСКОРИТЬ")
+	require.Len(t, posts.Posts, 1)
+	assert.Equal(t, 500001, posts.Posts[0].ID)
+
+	topics := search("topics", "медленный")
+	require.Len(t, topics.Topics, 1)
+	assert.Equal(t, 2, topics.Topics[0].ID)
+
+	users := search("users", "иванов")
+	require.Len(t, users.Users, 1)
+	assert.Equal(t, 2, users.Users[0].ID)
+}
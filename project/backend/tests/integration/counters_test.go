@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"forum-api-wrapper/internal/api"
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scraper"
+	"forum-api-wrapper/internal/service"
+)
+
+func TestSyncReconcilesCounters(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	svc := service.NewService(repo)
+	svc.SetSyncer(scraper.NewScraper(forum.URL, repo, scraper.Options{IgnoreRobots: true}))
+	server := httptest.NewServer(setupRouter(api.NewHandler(svc)))
+	defer server.Close()
+
+	ctx := context.Background()
+	// Counters as listed upstream, covering far more than is mirrored
+	require.NoError(t, repo.UpsertForum(ctx, &models.Forum{
+		ID: 1, Name: "Microsoft SQL Server", UpstreamTopicCount: 28412, UpstreamPostCount: 341903,
+	}))
+	require.NoError(t, repo.UpdateUserProfile(ctx, &models.User{ID: 1, Username: "testuser", UpstreamPostCount: 1204}))
+
+	topicID := 1001
+	job, err := svc.RunSync(ctx, service.SyncRequest{Scope: service.SyncScopeTopic, TargetID: &topicID}, service.SyncTriggerManual)
+	require.NoError(t, err)
+	assert.Equal(t, models.SyncJobSucceeded, job.Status)
+	assert.Greater(t, job.CountersCorrected, 0)
+
+	f, err := repo.GetForumByID(ctx, 1)
+	require.NoError(t, err)
+	// setupTestDB seeds topic 1 with one post in forum 1
+	assert.Equal(t, 2, f.TopicCount)
+	assert.Equal(t, 4, f.PostCount)
+	assert.Equal(t, 28412, f.UpstreamTopicCount)
+	assert.Equal(t, 341903, f.UpstreamPostCount)
+
+	topic, err := repo.GetTopicByID(ctx, 1001)
+	require.NoError(t, err)
+	assert.Equal(t, 2, topic.ReplyCount)
+
+	var userPosts, userTopics int
+	require.NoError(t, db.QueryRow("SELECT SUM(post_count), SUM(topic_count) FROM users").Scan(&userPosts, &userTopics))
+	assert.Equal(t, 4, userPosts)
+	assert.Equal(t, 2, userTopics)
+	user, err := repo.GetUserByID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.PostCount)
+	assert.Equal(t, 1204, user.UpstreamPostCount)
+
+	// Later syncs write the upstream counts only, so nothing drifts
+	require.NoError(t, repo.UpsertForum(ctx, &models.Forum{
+		ID: 1, Name: "Microsoft SQL Server", UpstreamTopicCount: 28413, UpstreamPostCount: 341910,
+	}))
+	require.NoError(t, repo.UpdateUserProfile(ctx, &models.User{ID: 1, Username: "testuser", UpstreamPostCount: 1205}))
+	again, err := svc.RunSync(ctx, service.SyncRequest{Scope: service.SyncScopeCounters}, service.SyncTriggerManual)
+	require.NoError(t, err)
+	assert.Equal(t, 0, again.CountersCorrected)
+
+	resp, err := adminClient.Get(server.URL + fmt.Sprintf("/api/admin/sync/jobs/%d/drift?limit=100", job.ID))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report service.CounterDriftResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, job.CountersCorrected, report.Pagination.Total)
+	assert.Contains(t, report.Drift, models.CounterDrift{
+		JobID: job.ID, Entity: "forum", EntityID: 1, Counter: "topic_count", Stored: 0, Actual: 2,
+	})
+	assert.Contains(t, report.Drift, models.CounterDrift{
+		JobID: job.ID, Entity: "forum", EntityID: 1, Counter: "post_count", Stored: 0, Actual: 4,
+	})
+
+	// A deleted post no longer counts; reconciling alone corrects it
+	_, err = db.Exec("UPDATE posts SET deleted_at = CURRENT_TIMESTAMP WHERE id = 500003")
+	require.NoError(t, err)
+	job, err = svc.RunSync(ctx, service.SyncRequest{Scope: service.SyncScopeCounters}, service.SyncTriggerManual)
+	require.NoError(t, err)
+	assert.Equal(t, models.SyncJobSucceeded, job.Status)
+	assert.Equal(t, 0, job.PagesFetched)
+
+	topic, err = repo.GetTopicByID(ctx, 1001)
+	require.NoError(t, err)
+	assert.Equal(t, 1, topic.ReplyCount)
+
+	drift, total, err := repo.GetCounterDrift(ctx, job.ID, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, job.CountersCorrected, total)
+	assert.Contains(t, drift, models.CounterDrift{
+		JobID: job.ID, Entity: "topic", EntityID: 1001, Counter: "reply_count", Stored: 2, Actual: 1,
+	})
+
+	// Nothing drifts when the counters are already right
+	job, err = svc.RunSync(ctx, service.SyncRequest{Scope: service.SyncScopeCounters}, service.SyncTriggerManual)
+	require.NoError(t, err)
+	assert.Equal(t, 0, job.CountersCorrected)
+
+	resp, err = adminClient.Get(server.URL + "/api/admin/sync/jobs/99/drift")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
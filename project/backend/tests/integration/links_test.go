@@ -0,0 +1,99 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"forum-api-wrapper/internal/api"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scraper"
+	"forum-api-wrapper/internal/service"
+)
+
+type topicLinks struct {
+	TopicID int `json:"topicId"`
+	Links   []struct {
+		SourcePostID     int     `json:"sourcePostId"`
+		SourceTopicID    int     `json:"sourceTopicId"`
+		URL              string  `json:"url"`
+		TargetTopicID    int     `json:"targetTopicId"`
+		TargetPostID     *int    `json:"targetPostId"`
+		TargetTopicTitle *string `json:"targetTopicTitle"`
+		Mirrored         bool    `json:"mirrored"`
+	} `json:"links"`
+	Pagination struct {
+		Total int `json:"total"`
+	} `json:"pagination"`
+}
+
+func TestTopicLinks(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+		"/topic.php?tid=1002&p=1": "topic_1002_page_1.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{})
+	ctx := context.Background()
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+	require.NoError(t, s.SyncPosts(ctx, 1002))
+
+	server := httptest.NewServer(setupRouter(api.NewHandler(service.NewService(repo))))
+	defer server.Close()
+
+	get := func(path string) topicLinks {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var links topicLinks
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&links))
+		return links
+	}
+
+	out := get("/api/topics/1002/outlinks")
+	require.Len(t, out.Links, 3)
+	assert.Equal(t, 3, out.Pagination.Total)
+
+	assert.Equal(t, 500101, out.Links[0].SourcePostID)
+	assert.Equal(t, 1001, out.Links[0].TargetTopicID)
+	assert.Nil(t, out.Links[0].TargetPostID)
+	assert.True(t, out.Links[0].Mirrored)
+	require.NotNil(t, out.Links[0].TargetTopicTitle)
+	assert.Equal(t, "Как ускорить MERGE на больших таблицах?", *out.Links[0].TargetTopicTitle)
+
+	assert.Equal(t, 500102, out.Links[1].SourcePostID)
+	require.NotNil(t, out.Links[1].TargetPostID)
+	assert.Equal(t, 500002, *out.Links[1].TargetPostID)
+
+	// Topic 2000 is linked but not mirrored
+	assert.Equal(t, 2000, out.Links[2].TargetTopicID)
+	assert.False(t, out.Links[2].Mirrored)
+	assert.Nil(t, out.Links[2].TargetTopicTitle)
+
+	back := get("/api/topics/1001/backlinks")
+	require.Len(t, back.Links, 2)
+	for _, link := range back.Links {
+		assert.Equal(t, 1002, link.SourceTopicID)
+		assert.Equal(t, 1001, link.TargetTopicID)
+	}
+
+	// Links within a topic and to other sites are neither
+	assert.Empty(t, get("/api/topics/1001/outlinks").Links)
+	assert.Empty(t, get("/api/topics/1002/backlinks").Links)
+
+	resp, err := http.Get(server.URL + "/api/topics/999/backlinks")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
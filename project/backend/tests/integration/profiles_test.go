@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"forum-api-wrapper/internal/api"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scraper"
+	"forum-api-wrapper/internal/service"
+)
+
+func TestScraperSyncProfiles(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+		"/profile.php?uid=55":     "profile_55.html",
+		"/profile.php?uid=17":     "profile_17.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{ProfileMaxAge: time.Hour})
+
+	ctx := context.Background()
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+	_, err := db.Exec("UPDATE users SET profile_synced_at = CURRENT_TIMESTAMP WHERE id = 1")
+	require.NoError(t, err)
+
+	stale, err := repo.GetStaleProfiles(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{17, 55}, stale)
+
+	require.NoError(t, s.SyncProfiles(ctx))
+	stale, err = repo.GetStaleProfiles(ctx, time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Empty(t, stale)
+
+	server := httptest.NewServer(setupRouter(api.NewHandler(service.NewService(repo))))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/users/55")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var user struct {
+		ID              int        `json:"id"`
+		Username        string     `json:"username"`
+		PostCount       int        `json:"upstreamPostCount"`
+		RegisteredAt    time.Time  `json:"registeredAt"`
+		LastActiveAt    *time.Time `json:"lastActiveAt"`
+		Location        string     `json:"location"`
+		Rank            string     `json:"rank"`
+		ProfileSyncedAt *time.Time `json:"profileSyncedAt"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&user))
+	assert.Equal(t, "ivanov", user.Username)
+	assert.Equal(t, 1204, user.PostCount)
+	assert.Equal(t, "Москва", user.Location)
+	assert.Equal(t, "Участник", user.Rank)
+	assert.True(t, user.RegisteredAt.Equal(time.Date(2008, time.January, 15, 7, 12, 0, 0, time.UTC)))
+	assert.NotNil(t, user.LastActiveAt)
+	assert.NotNil(t, user.ProfileSyncedAt)
+
+	aleks, err := repo.GetUserByID(ctx, 17)
+	require.NoError(t, err)
+	assert.Nil(t, aleks.LastActiveAt)
+	assert.Equal(t, "Заслуженный участник", aleks.Rank)
+}
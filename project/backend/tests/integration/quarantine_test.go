@@ -0,0 +1,176 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"forum-api-wrapper/internal/api"
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scraper"
+	"forum-api-wrapper/internal/service"
+)
+
+func TestScraperQuarantinesUnparsablePages(t *testing.T) {
+	pages := map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	}
+	forum := setupForumServer(t, pages)
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{})
+
+	ctx := context.Background()
+	require.NoError(t, s.ResyncPosts(ctx, 1001))
+
+	// A broken page is quarantined without failing the sync, and its posts
+	// are not taken as deleted
+	pages["/topic.php?tid=1001&p=2"] = "maintenance.html"
+	require.NoError(t, s.ResyncPosts(ctx, 1001))
+	require.NoError(t, s.ResyncPosts(ctx, 1001))
+
+	quarantined, total, err := repo.GetQuarantinedPages(ctx, 1, 20)
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, "/topic.php?tid=1001&p=2", quarantined[0].Path)
+	assert.Equal(t, forum.URL+"/topic.php?tid=1001&p=2", quarantined[0].URL)
+	assert.Equal(t, 2, quarantined[0].Attempts)
+	assert.Contains(t, quarantined[0].Error, "failed to parse topic 1001 page 2")
+	assert.Empty(t, quarantined[0].Body, "Expected the body left out of the list")
+
+	// Without an archive the page itself is kept for inspection
+	stored, err := repo.GetQuarantinedPageByID(ctx, quarantined[0].ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.BodyRef)
+	assert.Contains(t, stored.Body, "<html")
+
+	post, err := repo.GetPostByID(ctx, 500003)
+	require.NoError(t, err)
+	assert.Nil(t, post.DeletedAt)
+}
+
+func TestAdminQuarantine(t *testing.T) {
+	pages := map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "maintenance.html",
+	}
+	forum := setupForumServer(t, pages)
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{})
+	svc := service.NewService(repo)
+	svc.SetSyncer(s)
+	server := httptest.NewServer(setupRouter(api.NewHandler(svc)))
+	defer server.Close()
+
+	require.NoError(t, s.SyncPosts(context.Background(), 1001))
+
+	resp, err := adminClient.Get(server.URL + "/api/admin/quarantine")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list service.QuarantineListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Pages, 1)
+	id := list.Pages[0].ID
+
+	retry := func() service.QuarantineRetryResponse {
+		resp, err := adminClient.Post(server.URL+fmt.Sprintf("/api/admin/quarantine/%d/retry", id), "application/json", nil)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result service.QuarantineRetryResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return result
+	}
+
+	// Still broken upstream: the page stays quarantined
+	result := retry()
+	assert.False(t, result.Resolved)
+	require.NotNil(t, result.Page)
+	assert.Equal(t, 2, result.Page.Attempts)
+
+	// Fixed upstream: the page is ingested and released
+	pages["/topic.php?tid=1001&p=2"] = "topic_1001_page_2.html"
+	result = retry()
+	assert.True(t, result.Resolved)
+	job, err := svc.GetSyncJob(context.Background(), result.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, service.SyncScopeTopic, job.Scope)
+	assert.Equal(t, 1001, *job.TargetID)
+	assert.Equal(t, service.SyncTriggerRetry, job.Trigger)
+	assert.Equal(t, models.SyncJobSucceeded, job.Status)
+	post, err := repo.GetPostByID(context.Background(), 500003)
+	require.NoError(t, err)
+	assert.NotNil(t, post)
+	topic, err := repo.GetTopicByID(context.Background(), 1001)
+	require.NoError(t, err)
+	require.NotNil(t, topic.LastPostID)
+	assert.Equal(t, 500003, *topic.LastPostID)
+
+	resp, err = adminClient.Get(server.URL + fmt.Sprintf("/api/admin/quarantine/%d", id))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Discarding drops a page without ingesting it
+	require.NoError(t, repo.QuarantinePage(context.Background(), &models.QuarantinedPage{
+		URL: forum.URL + "/profile.php?uid=55", Path: "/profile.php?uid=55", Error: "broken",
+	}))
+	list = service.QuarantineListResponse{}
+	resp, err = adminClient.Get(server.URL + "/api/admin/quarantine")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Pages, 1)
+
+	req, err := http.NewRequest(http.MethodDelete, server.URL+fmt.Sprintf("/api/admin/quarantine/%d", list.Pages[0].ID), nil)
+	require.NoError(t, err)
+	resp, err = adminClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = adminClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestScraperRetryPage_KeepsRetriedPage(t *testing.T) {
+	pages := map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "maintenance.html",
+	}
+	forum := setupForumServer(t, pages)
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	cache, err := scraper.NewDiskCache(t.TempDir())
+	require.NoError(t, err)
+	s := scraper.NewScraper(forum.URL, repository.NewRepository(db), scraper.Options{Cache: cache})
+
+	ctx := context.Background()
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+	pages["/topic.php?tid=1001&p=2"] = "topic_1001_page_2.html"
+	require.NoError(t, s.RetryPage(ctx, "/topic.php?tid=1001&p=2"))
+
+	// The ingested page is cached, so the next sync sees it as unchanged
+	page, err := s.FetchPage(ctx, "/topic.php?tid=1001&p=2")
+	require.NoError(t, err)
+	assert.True(t, page.Unchanged)
+}
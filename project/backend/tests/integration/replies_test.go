@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"forum-api-wrapper/internal/api"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scraper"
+	"forum-api-wrapper/internal/service"
+)
+
+type replyNode struct {
+	ID      int         `json:"id"`
+	Replies []replyNode `json:"replies"`
+}
+
+func TestReplies(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{})
+	require.NoError(t, s.SyncPosts(context.Background(), 1001))
+
+	server := httptest.NewServer(setupRouter(api.NewHandler(service.NewService(repo))))
+	defer server.Close()
+
+	getReplies := func(postID string) []int {
+		resp, err := http.Get(server.URL + "/api/posts/" + postID + "/replies")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var replies struct {
+			Replies []struct {
+				ID int `json:"id"`
+			} `json:"replies"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&replies))
+		var ids []int
+		for _, r := range replies.Replies {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	// 500002 links its quote to 500001; 500003 names only aleks2, the author of 500002
+	assert.Equal(t, []int{500002}, getReplies("500001"))
+	assert.Equal(t, []int{500003}, getReplies("500002"))
+	assert.Empty(t, getReplies("500003"))
+
+	resp, err := http.Get(server.URL + "/api/posts/999/replies")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/api/topics/1001?view=tree")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var tree struct {
+		ID               int         `json:"id"`
+		Thread           []replyNode `json:"thread"`
+		ThreadPagination struct {
+			Total int `json:"total"`
+		} `json:"threadPagination"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tree))
+	assert.Equal(t, 1001, tree.ID)
+	assert.Equal(t, 1, tree.ThreadPagination.Total)
+	require.Len(t, tree.Thread, 1)
+	assert.Equal(t, 500001, tree.Thread[0].ID)
+	require.Len(t, tree.Thread[0].Replies, 1)
+	assert.Equal(t, 500002, tree.Thread[0].Replies[0].ID)
+	require.Len(t, tree.Thread[0].Replies[0].Replies, 1)
+	assert.Equal(t, 500003, tree.Thread[0].Replies[0].Replies[0].ID)
+
+	resp, err = http.Get(server.URL + "/api/topics/1001?view=nested")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
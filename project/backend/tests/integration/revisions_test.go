@@ -0,0 +1,169 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"forum-api-wrapper/internal/api"
+	"forum-api-wrapper/internal/diff"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scraper"
+	"forum-api-wrapper/internal/service"
+)
+
+func TestScraperResyncPosts_TracksEditsAndDeletions(t *testing.T) {
+	pages := map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	}
+	forum := setupForumServer(t, pages)
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{})
+
+	ctx := context.Background()
+	require.NoError(t, s.ResyncPosts(ctx, 1001))
+	// Resyncing unchanged pages must not record revisions
+	require.NoError(t, s.ResyncPosts(ctx, 1001))
+	revisions, err := repo.GetPostRevisions(ctx, 500003)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+
+	original, err := repo.GetPostByID(ctx, 500003)
+	require.NoError(t, err)
+	require.NotNil(t, original)
+
+	// Upstream, post 500003 is edited and post 500002 disappears
+	pages["/topic.php?tid=1001&p=1"] = "topic_1001_page_1_deleted.html"
+	pages["/topic.php?tid=1001&p=2"] = "topic_1001_page_2_edited.html"
+	require.NoError(t, s.ResyncPosts(ctx, 1001))
+
+	revisions, err = repo.GetPostRevisions(ctx, 500003)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, original.Content, revisions[0].Content)
+
+	edited, err := repo.GetPostByID(ctx, 500003)
+	require.NoError(t, err)
+	assert.Contains(t, edited.Content, "UPD: пачки по 50 000 строк.")
+	assert.Nil(t, edited.DeletedAt)
+
+	deleted, err := repo.GetPostByID(ctx, 500002)
+	require.NoError(t, err)
+	require.NotNil(t, deleted)
+	assert.NotNil(t, deleted.DeletedAt)
+
+	// A post that shows up again is restored
+	pages["/topic.php?tid=1001&p=1"] = "topic_1001_page_1.html"
+	require.NoError(t, s.ResyncPosts(ctx, 1001))
+	restored, err := repo.GetPostByID(ctx, 500002)
+	require.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+}
+
+func TestScraperFullSync_TracksEditsAndDeletions(t *testing.T) {
+	// Forums other than 1 and topics other than 1001 fail
+	pages := map[string]string{
+		"/":                       "forum_index.html",
+		"/forum.php?fid=1&p=1":    "forum_1_page_1.html",
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	}
+	forum := setupForumServer(t, pages)
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{PostsPerPage: 2, MaxTopicPages: 1})
+
+	ctx := context.Background()
+	require.Error(t, s.FullSync(ctx))
+
+	// Post 500002 disappears from the first page, which incremental syncs skip
+	pages["/topic.php?tid=1001&p=1"] = "topic_1001_page_1_deleted.html"
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+	post, err := repo.GetPostByID(ctx, 500002)
+	require.NoError(t, err)
+	assert.Nil(t, post.DeletedAt)
+
+	require.Error(t, s.FullSync(ctx))
+	post, err = repo.GetPostByID(ctx, 500002)
+	require.NoError(t, err)
+	assert.NotNil(t, post.DeletedAt)
+}
+
+func TestGetPostRevisions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	server := httptest.NewServer(setupRouter(api.NewHandler(service.NewService(repo))))
+	defer server.Close()
+
+	ctx := context.Background()
+	post, err := repo.GetPostByID(ctx, 1)
+	require.NoError(t, err)
+	post.Content = "Test post content<br>Edited"
+	require.NoError(t, repo.UpsertPost(ctx, post))
+
+	resp, err := http.Get(server.URL + "/api/posts/1/revisions")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var response service.PostRevisionsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, 1, response.PostID)
+	assert.Equal(t, "Test post content<br>Edited", response.Current.Content)
+	require.Len(t, response.Revisions, 1)
+	assert.Equal(t, "Test post content", response.Revisions[0].Content)
+	assert.Equal(t, []diff.Line{
+		{Op: diff.Delete, Text: "Test post content"},
+		{Op: diff.Insert, Text: "Test post content<br>"},
+		{Op: diff.Insert, Text: "Edited"},
+	}, response.Revisions[0].Diff)
+
+	resp, err = http.Get(server.URL + "/api/posts/999/revisions")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestUpsertPosts_RenderingChangesAreNotRevisions(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+
+	ctx := context.Background()
+	post, err := repo.GetPostByID(ctx, 1)
+	require.NoError(t, err)
+	post.Content = "<p>MERGE идёт <b>час</b></p>"
+	post.ContentText = "MERGE идёт час"
+	require.NoError(t, repo.UpsertPost(ctx, post))
+	revisions, err := repo.GetPostRevisions(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+
+	// A reparse with another renderer changes the markup but not the text
+	post.Content = "MERGE идёт <strong>час</strong>\n"
+	post.ContentText = "MERGE  идёт\nчас"
+	require.NoError(t, repo.UpsertPost(ctx, post))
+	revisions, err = repo.GetPostRevisions(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 1)
+
+	post.Content = "<p>MERGE идёт <b>минуту</b></p>"
+	post.ContentText = "MERGE идёт минуту"
+	require.NoError(t, repo.UpsertPost(ctx, post))
+	revisions, err = repo.GetPostRevisions(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 2)
+}
@@ -0,0 +1,620 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"forum-api-wrapper/internal/archive"
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scraper"
+)
+
+// setupForumServer serves scraper fixtures keyed by request URI, with an ETag
+// derived from the fixture name so conditional requests get 304 Not Modified
+func setupForumServer(t *testing.T, pages map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fixture, ok := pages[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		etag := `"` + fixture + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		body, err := os.ReadFile("../../internal/scraper/testdata/" + fixture)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Like the upstream forum, leave the charset to the page itself
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("ETag", etag)
+		w.Write(body)
+	}))
+}
+
+func TestScraperSyncForums(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/": "forum_index.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{})
+
+	ctx := context.Background()
+	require.NoError(t, s.SyncForums(ctx))
+	// A second sync must update rows in place rather than duplicate them
+	require.NoError(t, s.SyncForums(ctx))
+
+	// Forum 1 replaces the seeded test forum, which shares its ID
+	_, total, err := repo.GetForums(ctx, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	oracle, err := repo.GetForumByID(ctx, 3)
+	require.NoError(t, err)
+	require.NotNil(t, oracle)
+	assert.Equal(t, "Oracle", oracle.Name)
+	assert.Equal(t, 19077, oracle.UpstreamTopicCount)
+	assert.Equal(t, 204518, oracle.UpstreamPostCount)
+}
+
+func TestScraperSyncTopics(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/forum.php?fid=1&p=1": "forum_1_page_1.html",
+		"/forum.php?fid=1&p=2": "forum_1_page_2.html",
+	})
+	defer forum.Close()
+
+	tests := []struct {
+		name     string
+		opts     scraper.Options
+		expected []int
+	}{
+		{"full crawl", scraper.Options{}, []int{1, 950, 1000, 1001, 1002}},
+		{"page depth", scraper.Options{MaxTopicPages: 1}, []int{1, 1000, 1001, 1002}},
+		{"date cutoff", scraper.Options{TopicMaxAge: 30 * 24 * time.Hour}, []int{1, 1000, 1001, 1002}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			defer db.Close()
+			repo := repository.NewRepository(db)
+			s := scraper.NewScraper(forum.URL, repo, tt.opts)
+
+			ctx := context.Background()
+			_, err := s.SyncTopics(ctx, 1)
+			require.NoError(t, err)
+
+			forumID := 1
+			topics, _, err := repo.GetTopics(ctx, repository.TopicFilter{ForumID: &forumID}, 1, 100)
+			require.NoError(t, err)
+
+			var ids []int
+			for _, topic := range topics {
+				ids = append(ids, topic.ID)
+			}
+			assert.ElementsMatch(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestScraperSyncTopics_StoresAuthors(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/forum.php?fid=1&p=1": "forum_1_page_1.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{MaxTopicPages: 1})
+
+	ctx := context.Background()
+	_, err := s.SyncTopics(ctx, 1)
+	require.NoError(t, err)
+
+	topic, err := repo.GetTopicByID(ctx, 1001)
+	require.NoError(t, err)
+	require.NotNil(t, topic)
+	assert.Equal(t, "ivanov", topic.AuthorName)
+	assert.Equal(t, 12, topic.UpstreamReplyCount)
+	assert.Equal(t, 345, topic.ViewCount)
+	assert.NotNil(t, topic.LastPostAt)
+
+	user, err := repo.GetUserByID(ctx, 55)
+	require.NoError(t, err)
+	require.NotNil(t, user)
+	assert.Equal(t, "ivanov", user.Username)
+}
+
+func TestScraperSyncTopics_ReportsChangedTopics(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/forum.php?fid=1&p=1": "forum_1_page_1.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{MaxTopicPages: 1})
+
+	ctx := context.Background()
+	changed, err := s.SyncTopics(ctx, 1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{1000, 1001, 1002}, changed)
+
+	// Topic 1000 has no replies, so a single stored post makes it up to date
+	topic, err := repo.GetTopicByID(ctx, 1000)
+	require.NoError(t, err)
+	require.NotNil(t, topic)
+	require.NoError(t, repo.UpsertPost(ctx, &models.Post{
+		ID:          900000,
+		TopicID:     1000,
+		AuthorID:    topic.AuthorID,
+		Content:     "Правила раздела",
+		IsFirstPost: true,
+		CreatedAt:   *topic.LastPostAt,
+	}))
+
+	changed, err = s.SyncTopics(ctx, 1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{1001, 1002}, changed)
+}
+
+func TestScraperSyncPosts(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{})
+
+	ctx := context.Background()
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+
+	topic, err := repo.GetTopicByID(ctx, 1001)
+	require.NoError(t, err)
+	require.NotNil(t, topic)
+	assert.Equal(t, "Как ускорить MERGE на больших таблицах?", topic.Title)
+	assert.Equal(t, 1, topic.ForumID)
+	assert.Equal(t, "ivanov", topic.AuthorName)
+	require.NotNil(t, topic.LastPostID)
+	assert.Equal(t, 500003, *topic.LastPostID)
+
+	posts, total, err := repo.GetTopicPosts(ctx, 1001, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, posts, 3)
+	assert.True(t, posts[0].IsFirstPost)
+	assert.False(t, posts[1].IsFirstPost)
+	assert.Contains(t, posts[1].Content, "<pre><code class=\"language-sql\">WHILE 1 = 1\nBEGIN")
+	assert.Contains(t, posts[2].Content, "<blockquote><cite>aleks2</cite><blockquote>")
+}
+
+func TestScraperSyncPosts_ResumesAtLastStoredPage(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{PostsPerPage: 2})
+
+	ctx := context.Background()
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+
+	// With three stored posts and two per page only page 2 is fetched again,
+	// so the edit on page 1 survives while a full resync overwrites it
+	_, err := db.Exec("UPDATE posts SET content = 'edited' WHERE id = 500001")
+	require.NoError(t, err)
+
+	var content string
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+	require.NoError(t, db.QueryRow("SELECT content FROM posts WHERE id = 500001").Scan(&content))
+	assert.Equal(t, "edited", content)
+
+	require.NoError(t, s.ResyncPosts(ctx, 1001))
+	require.NoError(t, db.QueryRow("SELECT content FROM posts WHERE id = 500001").Scan(&content))
+	assert.NotEqual(t, "edited", content)
+}
+
+func TestScraperSyncPosts_ResumesAfterDeletions(t *testing.T) {
+	pages := map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	}
+	forum := setupForumServer(t, pages)
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{PostsPerPage: 2})
+
+	ctx := context.Background()
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+
+	// Two posts are deleted upstream, so the post that follows lands on page 1
+	_, err := db.Exec("UPDATE posts SET deleted_at = CURRENT_TIMESTAMP WHERE id IN (500002, 500003)")
+	require.NoError(t, err)
+	pages["/topic.php?tid=1001&p=1"] = "topic_1001_page_1_appended.html"
+	pages["/topic.php?tid=1001&p=2"] = "topic_1001_page_2_appended.html"
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+
+	for _, id := range []int{500004, 500005} {
+		post, err := repo.GetPostByID(ctx, id)
+		require.NoError(t, err)
+		assert.NotNil(t, post, "Expected post %d to be stored", id)
+	}
+}
+
+func TestScraperResyncPosts_KeepsLastPostOfUnchangedFinalPage(t *testing.T) {
+	pages := map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	}
+	forum := setupForumServer(t, pages)
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	cache, err := scraper.NewDiskCache(t.TempDir())
+	require.NoError(t, err)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{Cache: cache})
+
+	ctx := context.Background()
+	require.NoError(t, s.ResyncPosts(ctx, 1001))
+	want, err := repo.GetTopicByID(ctx, 1001)
+	require.NoError(t, err)
+	require.NotNil(t, want.LastPostID)
+	assert.Equal(t, 500003, *want.LastPostID)
+
+	// Only page 1 is ingested again; page 2 still ends the topic
+	pages["/topic.php?tid=1001&p=1"] = "topic_1001_page_1_edited.html"
+	require.NoError(t, s.ResyncPosts(ctx, 1001))
+
+	post, err := repo.GetPostByID(ctx, 500001)
+	require.NoError(t, err)
+	assert.Contains(t, post.Content, "UPD")
+	topic, err := repo.GetTopicByID(ctx, 1001)
+	require.NoError(t, err)
+	require.NotNil(t, topic.LastPostID)
+	assert.Equal(t, 500003, *topic.LastPostID)
+	assert.True(t, want.LastPostAt.Equal(*topic.LastPostAt))
+}
+
+func TestScraperSyncForum(t *testing.T) {
+	// Posts of topics 1000 and 1002 are missing upstream
+	forum := setupForumServer(t, map[string]string{
+		"/forum.php?fid=1&p=1":    "forum_1_page_1.html",
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{MaxTopicPages: 1, Concurrency: 3})
+
+	ctx := context.Background()
+	err := s.SyncForum(ctx, 1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "topic 1000")
+	assert.Contains(t, err.Error(), "topic 1002")
+
+	// A failing topic does not stop the others
+	_, total, err := repo.GetTopicPosts(ctx, 1001, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+}
+
+func TestScraperFullSync(t *testing.T) {
+	// Only forum 1 and topic 1001 are served; the other forums and topics fail
+	forum := setupForumServer(t, map[string]string{
+		"/":                       "forum_index.html",
+		"/forum.php?fid=1&p=1":    "forum_1_page_1.html",
+		"/forum.php?fid=1&p=2":    "forum_1_page_2.html",
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	cache, err := scraper.NewDiskCache(t.TempDir())
+	require.NoError(t, err)
+	opts := scraper.Options{MaxTopicPages: 1, TopicMaxAge: 30 * 24 * time.Hour, Cache: cache}
+	s := scraper.NewScraper(forum.URL, repo, opts)
+
+	ctx := context.Background()
+	require.Error(t, s.FullSync(ctx))
+
+	// The page depth and age cutoff of incremental syncs do not apply
+	topic, err := repo.GetTopicByID(ctx, 950)
+	require.NoError(t, err)
+	assert.NotNil(t, topic)
+
+	// Nor does stopping at unchanged listings: topics on them are still walked
+	_, err = db.Exec("DELETE FROM posts WHERE topic_id = 1001")
+	require.NoError(t, err)
+	require.NoError(t, s.SyncForum(ctx, 1))
+	_, total, err := repo.GetTopicPosts(ctx, 1001, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+
+	require.Error(t, s.FullSync(ctx))
+	_, total, err = repo.GetTopicPosts(ctx, 1001, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+}
+
+func TestScraperSyncForums_Windows1251(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/": "forum_index_cp1251.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper(forum.URL, repo, scraper.Options{})
+
+	ctx := context.Background()
+	require.NoError(t, s.SyncForums(ctx))
+
+	mssql, err := repo.GetForumByID(ctx, 1)
+	require.NoError(t, err)
+	require.NotNil(t, mssql)
+	assert.Equal(t, "Вопросы по Microsoft SQL Server, T-SQL и администрированию", mssql.Description)
+}
+
+func TestScraperRobotsAndUserAgent(t *testing.T) {
+	var userAgents []string
+	forum := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.UserAgent())
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /topic.php\n"))
+		case "/":
+			body, _ := os.ReadFile("../../internal/scraper/testdata/forum_index.html")
+			w.Write(body)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	opts := scraper.Options{UserAgent: "test-mirror/0.1"}
+	s := scraper.NewScraper(forum.URL, repository.NewRepository(db), opts)
+
+	ctx := context.Background()
+	require.NoError(t, s.SyncForums(ctx))
+
+	err := s.SyncPosts(ctx, 1001)
+	assert.ErrorIs(t, err, scraper.ErrDisallowed)
+
+	// robots.txt is fetched once and the disallowed page never requested
+	assert.Equal(t, []string{"test-mirror/0.1", "test-mirror/0.1"}, userAgents)
+}
+
+func TestScraperPageCache_SkipsUnchangedPages(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/":                       "forum_index.html",
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	cache, err := scraper.NewDiskCache(t.TempDir())
+	require.NoError(t, err)
+	s := scraper.NewScraper(forum.URL, repository.NewRepository(db), scraper.Options{Cache: cache})
+
+	ctx := context.Background()
+	require.NoError(t, s.SyncForums(ctx))
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+
+	// Local edits survive a resync of unchanged pages because nothing is rewritten
+	_, err = db.Exec("UPDATE forums SET name = 'edited' WHERE id = 3")
+	require.NoError(t, err)
+	_, err = db.Exec("UPDATE posts SET content = 'edited' WHERE id = 500003")
+	require.NoError(t, err)
+
+	require.NoError(t, s.SyncForums(ctx))
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+
+	var name, content string
+	require.NoError(t, db.QueryRow("SELECT name FROM forums WHERE id = 3").Scan(&name))
+	require.NoError(t, db.QueryRow("SELECT content FROM posts WHERE id = 500003").Scan(&content))
+	assert.Equal(t, "edited", name)
+	assert.Equal(t, "edited", content)
+}
+
+func TestScraperPageCache_FillsFreshDatabase(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/":                       "forum_index.html",
+		"/forum.php?fid=1&p=1":    "forum_1_page_1.html",
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+	defer forum.Close()
+
+	cache, err := scraper.NewDiskCache(t.TempDir())
+	require.NoError(t, err)
+	opts := scraper.Options{MaxTopicPages: 1, Cache: cache}
+	ctx := context.Background()
+
+	sync := func(repo repository.Repository) {
+		s := scraper.NewScraper(forum.URL, repo, opts)
+		require.NoError(t, s.SyncForums(ctx))
+		_, err := s.SyncTopics(ctx, 1)
+		require.NoError(t, err)
+		require.NoError(t, s.ResyncPosts(ctx, 1001))
+	}
+
+	first := setupTestDB(t)
+	defer first.Close()
+	sync(repository.NewRepository(first))
+
+	// The database is recreated but the cache is kept: every page is now
+	// answered with 304 and must still be stored
+	fresh := setupTestDB(t)
+	defer fresh.Close()
+	repo := repository.NewRepository(fresh)
+	sync(repo)
+
+	forum3, err := repo.GetForumByID(ctx, 3)
+	require.NoError(t, err)
+	assert.NotNil(t, forum3)
+	topic, err := repo.GetTopicByID(ctx, 1001)
+	require.NoError(t, err)
+	assert.NotNil(t, topic)
+	post, err := repo.GetPostByID(ctx, 500003)
+	require.NoError(t, err)
+	assert.NotNil(t, post)
+}
+
+func TestScraperReparse_RebuildsFromArchive(t *testing.T) {
+	// The forum may live below the root of its host, as on www.resql.ru/forum
+	for _, root := range []string{"", "/forum"} {
+		t.Run("root "+root+"/", func(t *testing.T) {
+			testReparse(t, root)
+		})
+	}
+}
+
+func testReparse(t *testing.T, root string) {
+	forum := setupForumServer(t, map[string]string{
+		root + "/":                       "forum_index_cp1251.html",
+		root + "/forum.php?fid=1&p=1":    "forum_1_page_1.html",
+		root + "/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		root + "/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+
+	store, err := archive.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	live := setupTestDB(t)
+	defer live.Close()
+	liveRepo := repository.NewRepository(live)
+	s := scraper.NewScraper(forum.URL+root, liveRepo, scraper.Options{MaxTopicPages: 1, IgnoreRobots: true, Archive: store})
+
+	ctx := context.Background()
+	require.NoError(t, s.SyncForums(ctx))
+	_, err = s.SyncTopics(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, s.SyncPosts(ctx, 1001))
+
+	// Rebuild a fresh database with the forum gone
+	forum.Close()
+	rebuilt := setupTestDB(t)
+	defer rebuilt.Close()
+	rebuiltRepo := repository.NewRepository(rebuilt)
+	offline := scraper.NewScraper(forum.URL+root, rebuiltRepo, scraper.Options{})
+	require.NoError(t, offline.Reparse(ctx, store))
+
+	mssql, err := rebuiltRepo.GetForumByID(ctx, 1)
+	require.NoError(t, err)
+	require.NotNil(t, mssql)
+	assert.Equal(t, "Вопросы по Microsoft SQL Server, T-SQL и администрированию", mssql.Description)
+
+	want, err := liveRepo.GetTopicByID(ctx, 1001)
+	require.NoError(t, err)
+	got, err := rebuiltRepo.GetTopicByID(ctx, 1001)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, want.Title, got.Title)
+	assert.Equal(t, want.UpstreamReplyCount, got.UpstreamReplyCount)
+	assert.Equal(t, want.LastPostID, got.LastPostID)
+	// Relative dates resolve against the archived fetch time, not the reparse time
+	assert.True(t, want.LastPostAt.Equal(*got.LastPostAt))
+
+	wantPosts, _, err := liveRepo.GetTopicPosts(ctx, 1001, 1, 20)
+	require.NoError(t, err)
+	gotPosts, _, err := rebuiltRepo.GetTopicPosts(ctx, 1001, 1, 20)
+	require.NoError(t, err)
+	require.Len(t, gotPosts, len(wantPosts))
+	for i := range wantPosts {
+		assert.Equal(t, wantPosts[i].Content, gotPosts[i].Content)
+		assert.Equal(t, wantPosts[i].IsFirstPost, gotPosts[i].IsFirstPost)
+		assert.True(t, wantPosts[i].CreatedAt.Equal(gotPosts[i].CreatedAt))
+	}
+}
+
+func TestScraperReparse_NoForumPages(t *testing.T) {
+	store, err := archive.NewStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, store.Put(&archive.Record{
+		URL:        "https://www.resql.ru/forum/rules.php",
+		Path:       "/rules.php",
+		StatusCode: http.StatusOK,
+	}, []byte("<html></html>")))
+
+	db := setupTestDB(t)
+	defer db.Close()
+	s := scraper.NewScraper("", repository.NewRepository(db), scraper.Options{})
+
+	// Nothing to rebuild from is reported rather than passing silently
+	assert.Error(t, s.Reparse(context.Background(), store))
+}
+
+func TestScraperDirSource_SyncsOffline(t *testing.T) {
+	dir := t.TempDir()
+	source := scraper.NewDirSource(dir)
+	saved := map[string]string{
+		"/":                       "forum_index.html",
+		"/forum.php?fid=1&p=1":    "forum_1_page_1.html",
+		"/forum.php?fid=1&p=2":    "forum_1_page_2.html",
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	}
+	for path, fixture := range saved {
+		body, err := os.ReadFile("../../internal/scraper/testdata/" + fixture)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, source.File(path)), body, 0o644))
+	}
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	s := scraper.NewScraper("", repo, scraper.Options{Source: source})
+
+	// Topics 1000 and 1002 have no saved pages and are reported as failures
+	ctx := context.Background()
+	require.NoError(t, s.SyncForums(ctx))
+	err := s.SyncForum(ctx, 1)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "topic 1001")
+
+	_, total, err := repo.GetTopicPosts(ctx, 1001, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+}
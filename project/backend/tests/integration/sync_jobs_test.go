@@ -0,0 +1,216 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"forum-api-wrapper/internal/api"
+	"forum-api-wrapper/internal/models"
+	"forum-api-wrapper/internal/repository"
+	"forum-api-wrapper/internal/scraper"
+	"forum-api-wrapper/internal/service"
+)
+
+func TestAdminSync(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{
+		"/topic.php?tid=1001&p=1": "topic_1001_page_1.html",
+		"/topic.php?tid=1001&p=2": "topic_1001_page_2.html",
+	})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	svc := service.NewService(repo)
+	svc.SetSyncer(scraper.NewScraper(forum.URL, repo, scraper.Options{IgnoreRobots: true}))
+	server := httptest.NewServer(setupRouter(api.NewHandler(svc)))
+	defer server.Close()
+
+	body := bytes.NewBufferString(`{"scope": "topic", "targetId": 1001}`)
+	resp, err := adminClient.Post(server.URL+"/api/admin/sync", "application/json", body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var started models.SyncJob
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&started))
+	assert.Equal(t, "topic", started.Scope)
+	assert.Equal(t, models.SyncJobRunning, started.Status)
+
+	// The sync runs in the background; poll until it is recorded as finished
+	var job models.SyncJob
+	require.Eventually(t, func() bool {
+		resp, err := adminClient.Get(server.URL + fmt.Sprintf("/api/admin/sync/jobs/%d", started.ID))
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return false
+		}
+		defer resp.Body.Close()
+		job = models.SyncJob{}
+		return json.NewDecoder(resp.Body).Decode(&job) == nil && job.Status != models.SyncJobRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, models.SyncJobSucceeded, job.Status)
+	assert.Equal(t, "manual", job.Trigger)
+	assert.Equal(t, 2, job.PagesFetched)
+	assert.Greater(t, job.RowsUpserted, 0)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Empty(t, job.Errors)
+
+	resp, err = adminClient.Get(server.URL + "/api/admin/sync/jobs")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var list service.SyncJobListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Equal(t, 1, list.Pagination.Total)
+}
+
+func TestAdminSync_RejectsOverlappingJobs(t *testing.T) {
+	// The forum holds the full sync on its first page until released
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	forum := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(requested) })
+		<-release
+		http.NotFound(w, r)
+	}))
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	svc := service.NewService(repo)
+	svc.SetSyncer(scraper.NewScraper(forum.URL, repo, scraper.Options{IgnoreRobots: true}))
+	server := httptest.NewServer(setupRouter(api.NewHandler(svc)))
+	defer server.Close()
+
+	post := func(body string) int {
+		resp, err := adminClient.Post(server.URL+"/api/admin/sync", "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusAccepted, post(`{"scope": "full"}`))
+	<-requested
+
+	// The full sync covers every forum and their topics
+	assert.Equal(t, http.StatusConflict, post(`{"scope": "forum", "targetId": 1}`))
+	assert.Equal(t, http.StatusConflict, post(`{"scope": "topic", "targetId": 1}`))
+
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, svc.Shutdown(ctx))
+}
+
+func TestAdminSync_RecordsFailures(t *testing.T) {
+	forum := setupForumServer(t, map[string]string{})
+	defer forum.Close()
+
+	db := setupTestDB(t)
+	defer db.Close()
+	repo := repository.NewRepository(db)
+	svc := service.NewService(repo)
+	svc.SetSyncer(scraper.NewScraper(forum.URL, repo, scraper.Options{IgnoreRobots: true}))
+
+	ctx := context.Background()
+	targetID := 404
+	job, err := svc.RunSync(ctx, service.SyncRequest{Scope: "forum", TargetID: &targetID}, service.SyncTriggerScheduled)
+	require.NoError(t, err)
+
+	stored, err := svc.GetSyncJob(ctx, job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SyncJobFailed, stored.Status)
+	assert.Equal(t, "scheduled", stored.Trigger)
+	require.Len(t, stored.Errors, 1)
+	assert.Contains(t, stored.Errors[0], "failed to fetch forum 404 page 1")
+}
+
+func TestAdminSync_RejectsInvalidRequests(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	svc := service.NewService(repository.NewRepository(db))
+	svc.SetSyncer(scraper.NewScraper("http://127.0.0.1:0", repository.NewRepository(db), scraper.Options{}))
+	server := httptest.NewServer(setupRouter(api.NewHandler(svc)))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"unknown scope", `{"scope": "everything"}`},
+		{"forum without target", `{"scope": "forum"}`},
+		{"full with target", `{"scope": "full", "targetId": 1}`},
+		{"malformed", `{`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := adminClient.Post(server.URL+"/api/admin/sync", "application/json", bytes.NewBufferString(tt.body))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+
+	resp, err := adminClient.Get(server.URL + "/api/admin/sync/jobs/1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAdminRequiresToken(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	handler := api.NewHandler(service.NewService(repository.NewRepository(db)))
+	server := httptest.NewServer(setupRouter(handler))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer guess", http.StatusUnauthorized},
+		{"token without scheme", testAdminToken, http.StatusUnauthorized},
+		{"admin token", "Bearer " + testAdminToken, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/api/admin/sync/jobs", nil)
+			require.NoError(t, err)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.want, resp.StatusCode)
+		})
+	}
+
+	// Without a token the admin endpoints do not exist
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api.RegisterRoutes(router, handler, "")
+	open := httptest.NewServer(router)
+	defer open.Close()
+
+	resp, err := adminClient.Get(open.URL + "/api/admin/sync/jobs")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}